@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/pool"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// allocAttempts is how often alloc reads and writes the hosts data when
+// other writers change it in between.
+const allocAttempts = 10
+
+var (
+	allocWeight int
+	allocTTL    uint32
+)
+
+// allocCmd represents the alloc command.
+var allocCmd = &cobra.Command{
+	Use:   "alloc POOL HOSTNAME",
+	Short: "Add a record with the next free address of a pool",
+	Long: `Add a record for HOSTNAME with the lowest address of POOL that no record
+uses, and print the address.
+
+Pools are defined under pools: in the config, each a CIDR with optional
+exclusions. The network and broadcast addresses of IPv4 pools and the
+first address of IPv6 pools are never allocated.
+
+The record is written with a compare-and-swap on the hosts version, so
+concurrent allocations never hand out the same address: the loser reads
+the new data and picks the next free address. If HOSTNAME already has an
+address in the pool, that address is printed and nothing is written.
+
+Example:
+  dnsctl alloc lab vm42.lab.example.com
+  dnsctl alloc lab vm42.lab.example.com --ttl 300 -m "new build agent"
+  IP=$(dnsctl alloc lab vm42.lab.example.com | awk '{print $2}')`,
+	Args: cobra.ExactArgs(2),
+	RunE: runAlloc,
+}
+
+func init() {
+	rootCmd.AddCommand(allocCmd)
+
+	allocCmd.Flags().IntVar(&allocWeight, "weight", 0, "weight of the new record")
+	allocCmd.Flags().Uint32Var(&allocTTL, "ttl", 0, "TTL of the new record in seconds")
+	addMessageFlag(allocCmd)
+	addVerifyFlags(allocCmd)
+}
+
+func runAlloc(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if allocWeight < 0 {
+		return errdefs.Errorf(errdefs.Usage, "--weight must not be negative")
+	}
+	hostname, err := records.ParseHostname(args[1])
+	if err != nil {
+		return errdefs.Errorf(errdefs.Validation, "invalid hostname %q: %v", args[1], err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	p, err := configPool(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	mode, err := cli.Mode()
+	if err != nil {
+		return err
+	}
+	if mode == client.ModePerHost {
+		return errdefs.New(errdefs.Usage, "alloc is not supported in perhost mode")
+	}
+
+	if err := confirmTarget(fmt.Sprintf("Allocate an address from pool %s to %s", name, hostname)); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		hosts, err := cli.Read()
+		if err != nil {
+			return err
+		}
+		oldRecs := hosts.Records()
+		for _, r := range oldRecs {
+			if records.NormalizeHostname(r.Hostname) == hostname && p.Prefix.Contains(records.Addr(r.IP)) {
+				fmt.Printf("Allocated: %s -> %s (pool %s, existing record)\n", records.Addr(r.IP), hostname, name)
+				return nil
+			}
+		}
+
+		addr, err := p.Next(oldRecs)
+		if errors.Is(err, pool.ErrExhausted) {
+			return errdefs.Wrap(errdefs.Validation, err)
+		}
+		if err != nil {
+			return err
+		}
+		rec := client.Record{Hostname: hostname, IP: net.IP(addr.AsSlice()), Weight: allocWeight, TTL: allocTTL}
+		if err := hosts.Add(records.Canonicalize(rec)); err != nil {
+			return err
+		}
+
+		err = cli.Write(hosts)
+		if errdefs.KindOf(err) == errdefs.Conflict && attempt < allocAttempts {
+			fmt.Fprintf(os.Stderr, "Warning: hosts data changed while allocating, retrying (%d/%d)\n", attempt, allocAttempts-1)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		recordAudit(cli, newAuditEntry("alloc"))
+
+		fmt.Printf("Allocated: %s -> %s (pool %s)\n", addr, hostname, name)
+		return verifyWrite(oldRecs, hosts.Records())
+	}
+}
+
+// configPool returns the pool called name from cfg.
+func configPool(cfg *config.Config, name string) (*pool.Pool, error) {
+	if _, ok := cfg.Pools[name]; !ok {
+		if len(cfg.Pools) == 0 {
+			return nil, errdefs.Errorf(errdefs.Config, "no pools configured, define them under pools: in the config")
+		}
+		return nil, &errdefs.Error{
+			Kind:    errdefs.NotFound,
+			Err:     fmt.Errorf("pool %q not found", name),
+			Details: []string{"configured pools: " + strings.Join(cfg.PoolNames(), ", ")},
+		}
+	}
+	p, err := cfg.Pool(name)
+	if err != nil {
+		return nil, errdefs.Errorf(errdefs.Config, "pools.%s: %v", name, err)
+	}
+	return p, nil
+}
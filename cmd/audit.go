@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/audit"
+)
+
+var changeMessage string
+
+// addMessageFlag adds the -m/--message flag to a mutating command.
+func addMessageFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&changeMessage, "message", "m", "", "describe the change for 'dnsctl history'")
+}
+
+// newAuditEntry returns an audit entry for the running command.
+func newAuditEntry(command string) audit.Entry {
+	return audit.NewEntry(command, changeMessage, shortVersion())
+}
+
+// newAuditStore creates an audit store for the configured hosts key.
+func newAuditStore() (*audit.Store, func(), error) {
+	etcd, cfg, err := newEtcdClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	store := audit.NewStore(etcd, audit.KeyPrefix(cfg.Key), cfg.ReqTimeout)
+	return store, func() { _ = etcd.Close() }, nil
+}
+
+// recordAudit stores entry for the revision of the last write by cli
+// unless its revision is already set. The write has already succeeded, so
+// failures are reported as warnings.
+func recordAudit(cli *hostsClient, entry audit.Entry) {
+	if err := writeAudit(cli, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record change annotation: %v\n", err)
+	}
+}
+
+func writeAudit(cli *hostsClient, entry audit.Entry) error {
+	if entry.Revision == 0 {
+		entry.Revision = cli.revision
+	}
+
+	store, closeStore, err := newAuditStore()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	return store.Record(entry)
+}
+
+// loadAudit returns all audit entries keyed by revision.
+// Missing annotations are not an error, so failures yield an empty map.
+func loadAudit() map[int64]*audit.Entry {
+	store, closeStore, err := newAuditStore()
+	if err != nil {
+		return nil
+	}
+	defer closeStore()
+
+	entries, err := store.List()
+	if err != nil {
+		return nil
+	}
+	return entries
+}
+
+// shortVersion returns the dnsctl version without build date and commit.
+func shortVersion() string {
+	if fields := strings.Fields(rootCmd.Version); len(fields) > 0 {
+		return fields[0]
+	}
+	return "dev"
+}
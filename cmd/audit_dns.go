@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/dnsprobe"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+var (
+	auditDNSServer  string
+	auditDNSSamples int
+	auditDNSAll     bool
+	auditDNSOutput  string
+)
+
+// auditDNSCmd represents the audit-dns command.
+var auditDNSCmd = &cobra.Command{
+	Use:   "audit-dns [HOSTNAME|GLOB...] --server SERVER[:PORT]",
+	Short: "Report hostnames a DNS server answers differently from etcd",
+	Long: `Resolve every hostname of the current dataset against a DNS server,
+such as CoreDNS with the etcdhosts plugin, and report hostnames whose A
+and AAAA answers differ from the records stored in etcd. Use it to find
+plugin instances that stopped syncing.
+
+Differences are reported as:
+  missing - stored addresses the server did not answer with
+  extra   - answered addresses the hostname never had
+  stale   - answered addresses the hostname had in an earlier revision
+
+Each hostname is queried up to --samples times, so a server answering
+with one of several weighted records per query is audited fully.
+
+Without arguments all hostnames are audited. Only hostnames with drift
+or errors are shown unless --all is given. The exit code is 1 if any
+hostname has drift or could not be queried.
+
+` + output.FormatHelp(output.DataFormats, map[output.Format]string{
+		output.FormatWide: "table with the number of samples and errors",
+	}) + `
+Example:
+  dnsctl audit-dns --server 10.0.0.53:53
+  dnsctl audit-dns '*.example.com' --server 10.0.0.53 --all -o wide`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runAuditDNS,
+}
+
+func init() {
+	rootCmd.AddCommand(auditDNSCmd)
+
+	auditDNSCmd.Flags().StringVar(&auditDNSServer, "server", "", "DNS server to query, as HOST[:PORT]")
+	auditDNSCmd.Flags().IntVar(&auditDNSSamples, "samples", 10, "most queries per hostname and address family")
+	auditDNSCmd.Flags().BoolVar(&auditDNSAll, "all", false, "also show hostnames without drift")
+	auditDNSCmd.Flags().StringVarP(&auditDNSOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+	_ = auditDNSCmd.MarkFlagRequired("server")
+}
+
+func runAuditDNS(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(auditDNSOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+	server, err := dnsprobe.Server(auditDNSServer)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Usage, err)
+	}
+	if auditDNSSamples < 1 {
+		return errdefs.Errorf(errdefs.Usage, "--samples must be at least 1")
+	}
+	var filter records.Filter
+	for _, arg := range args {
+		p, err := records.ParsePattern(arg)
+		if err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+		filter.Patterns = append(filter.Patterns, p)
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+	recs := filter.Apply(hosts.Records())
+	if len(recs) == 0 && len(args) > 0 {
+		return errdefs.Errorf(errdefs.NotFound, "no records for %s", strings.Join(args, ", "))
+	}
+
+	past, err := pastAddresses(cli, hostnamesOf(recs))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read history, stale answers are reported as extra: %v\n", err)
+	}
+
+	drifts := dnsprobe.Audit(commandContext(), server, recs, past, dnsprobe.AuditOptions{
+		Samples:      auditDNSSamples,
+		QueryTimeout: verifyQueryTimeout,
+	})
+	var failed []dnsprobe.Drift
+	for _, d := range drifts {
+		if d.Status != dnsprobe.DriftNone {
+			failed = append(failed, d)
+		}
+	}
+
+	shown := failed
+	if auditDNSAll {
+		shown = drifts
+	}
+	if format == output.FormatTable || format == output.FormatWide {
+		fmt.Printf("Audited %d hostname(s) against %s: %d with drift.\n", len(drifts), server, len(failed))
+		if len(shown) == 0 {
+			return nil
+		}
+		fmt.Println()
+	}
+	if shown == nil {
+		shown = []dnsprobe.Drift{}
+	}
+	if err := output.Print(dnsprobe.Drifts(shown), format); err != nil {
+		return err
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	details := make([]string, len(failed))
+	for i, d := range failed {
+		details[i] = fmt.Sprintf("%s: %s", d.Hostname, driftDetail(d))
+	}
+	return &errdefs.Error{
+		Kind:    errdefs.General,
+		Err:     fmt.Errorf("%d hostname(s) answered differently by %s", len(failed), server),
+		Details: details,
+	}
+}
+
+// pastAddresses returns the addresses hostnames had in the key history,
+// read per domain in per-host mode like 'history --all' does.
+func pastAddresses(cli *hostsClient, hostnames []string) (map[string][]net.IP, error) {
+	mode, err := cli.Mode()
+	if err != nil {
+		return nil, err
+	}
+	if mode != client.ModePerHost {
+		versions, err := cli.History()
+		if err != nil {
+			return nil, err
+		}
+		return dnsprobe.PastAddresses(versions), nil
+	}
+
+	histories, err := fetchDomainHistories(cli, hostnames)
+	if err != nil {
+		return nil, err
+	}
+	var versions []*client.Hosts
+	for _, history := range histories {
+		versions = append(versions, history...)
+	}
+	return dnsprobe.PastAddresses(versions), nil
+}
+
+// driftDetail describes the drift of a hostname in one line.
+func driftDetail(d dnsprobe.Drift) string {
+	if d.Error != "" {
+		return d.Error
+	}
+	var parts []string
+	for _, p := range []struct {
+		name string
+		ips  []string
+	}{{"missing", d.Missing}, {"extra", d.Extra}, {"stale", d.Stale}} {
+		if len(p.ips) > 0 {
+			parts = append(parts, p.name+" "+strings.Join(p.ips, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/blame"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+var blameOutput string
+
+// blameCmd represents the blame command.
+var blameCmd = &cobra.Command{
+	Use:   "blame [HOSTNAME]",
+	Short: "Show which revision introduced each record",
+	Long: `Show which revision introduced each current record.
+
+For every record the history is walked back to find:
+  ADDED   - the revision where the hostname/IP pair appeared
+  CHANGED - the revision where the record took its current form
+            (weight, TTL and health check)
+
+The author is shown for changes annotated by dnsctl (see 'dnsctl history').
+
+` + output.FormatHelp(output.DataFormats, nil) + `
+Example:
+  dnsctl blame
+  dnsctl blame api.example.com
+  dnsctl blame -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBlame,
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd)
+
+	blameCmd.Flags().StringVarP(&blameOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+}
+
+func runBlame(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(blameOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+
+	var hostname string
+	if len(args) > 0 {
+		hostname = args[0]
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	lines, err := blameRecords(cli, hostname)
+	if err != nil {
+		return err
+	}
+
+	annotations := loadAudit()
+	for i := range lines {
+		if e, ok := annotations[lines[i].ChangedRevision]; ok {
+			lines[i].Author = e.Author()
+			lines[i].Message = e.Message
+		}
+	}
+
+	if format != output.FormatTable {
+		return output.Print(blame.Lines(lines), format)
+	}
+
+	if len(lines) == 0 {
+		if hostname != "" {
+			fmt.Printf("No records for %s.\n", hostname)
+		} else {
+			fmt.Println("No records found.")
+		}
+		return nil
+	}
+	printBlameTable(lines)
+	return nil
+}
+
+// blameRecords computes blame lines from the key history, per domain in
+// per-host mode.
+func blameRecords(cli *hostsClient, hostname string) ([]blame.Line, error) {
+	mode, err := cli.Mode()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != client.ModePerHost {
+		history, err := cli.History()
+		if err != nil {
+			return nil, err
+		}
+		return blame.Compute(history, hostname), nil
+	}
+
+	domains := []string{hostname}
+	if hostname == "" {
+		if domains, err = cli.ListDomains(); err != nil {
+			return nil, err
+		}
+	}
+
+	var lines []blame.Line
+	for _, d := range domains {
+		history, err := cli.HistoryHost(d)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, blame.Compute(history, hostname)...)
+	}
+	return lines, nil
+}
+
+// printBlameTable prints current records with the revisions that
+// introduced and last changed them.
+func printBlameTable(lines []blame.Line) {
+	fmt.Printf("%-32s  %-24s  %-10s  %-19s  %-10s  %-19s  %s\n",
+		"HOSTNAME", "IP", "ADDED", "ADDED AT", "CHANGED", "CHANGED AT", "AUTHOR")
+	fmt.Println("--------------------------------  ------------------------  ----------  -------------------  ----------  -------------------  --------------------")
+
+	truncated := false
+	for _, l := range lines {
+		added := fmt.Sprintf("%d", l.AddedRevision)
+		if l.Truncated {
+			added += "*"
+			truncated = true
+		}
+
+		fmt.Printf("%-32s  %-24s  %-10s  %-19s  %-10d  %-19s  %s\n",
+			l.Hostname,
+			l.IP,
+			added,
+			output.FormatTime(l.AddedAt),
+			l.ChangedRevision,
+			output.FormatTime(l.ChangedAt),
+			output.OrDash(l.Author),
+		)
+	}
+
+	fmt.Printf("\nTotal: %d records\n", len(lines))
+	if truncated {
+		fmt.Println("* present in the oldest available version, may have been added earlier.")
+	}
+}
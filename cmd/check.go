@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/healthcheck"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+var (
+	checkOutput  string
+	checkWorkers int
+	checkTimeout time.Duration
+)
+
+// checkCmd represents the check command.
+var checkCmd = &cobra.Command{
+	Use:   "check [HOSTNAME|GLOB...]",
+	Short: "Run the health checks of records",
+	Long: `Run the health checks configured on records (icmp, tcp, http and https)
+from this machine and report whether each backend is up.
+
+Checks run concurrently, --workers at a time, each limited to
+--check-timeout. HTTP checks send a GET request to the IP with the
+hostname as Host header and pass on any 2xx or 3xx status; certificates
+are not verified. ICMP checks need an unprivileged ICMP socket
+(net.ipv4.ping_group_range) or root.
+
+Records without a health check are shown as "none" and count as healthy.
+The exit code is 1 if any hostname has no healthy record.
+
+` + output.FormatHelp(output.DataFormats, map[output.Format]string{
+		output.FormatWide: "same as table",
+	}) + `
+Example:
+  dnsctl check
+  dnsctl check api.example.com '*.internal.example.com'
+  dnsctl check --workers 64 --check-timeout 1s -o json`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringVarP(&checkOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+	checkCmd.Flags().IntVar(&checkWorkers, "workers", 16, "number of checks run at the same time")
+	checkCmd.Flags().DurationVar(&checkTimeout, "check-timeout", 2*time.Second, "timeout of each check")
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(checkOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+	if checkWorkers < 1 {
+		return errdefs.Errorf(errdefs.Usage, "invalid number of workers: %d", checkWorkers)
+	}
+	if checkTimeout <= 0 {
+		return errdefs.Errorf(errdefs.Usage, "invalid check timeout: %s", checkTimeout)
+	}
+	var filter records.Filter
+	for _, arg := range args {
+		p, err := records.ParsePattern(arg)
+		if err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+		filter.Patterns = append(filter.Patterns, p)
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+	recs := filter.Apply(hosts.Records())
+	if len(recs) == 0 && len(args) > 0 {
+		return errdefs.Errorf(errdefs.NotFound, "no records for %s", strings.Join(args, ", "))
+	}
+
+	ctx := commandContext()
+	results := healthcheck.Run(ctx, recs, healthcheck.Options{Workers: checkWorkers, Timeout: checkTimeout})
+	if err := ctx.Err(); err != nil {
+		return context.Cause(ctx)
+	}
+
+	if err := output.Print(healthcheck.Results(results), format); err != nil {
+		return err
+	}
+
+	var unhealthy []string
+	for _, s := range healthcheck.Summarize(results) {
+		if s.Healthy == 0 {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s: 0 of %d healthy", s.Hostname, s.Total))
+		}
+	}
+	if len(unhealthy) > 0 {
+		return &errdefs.Error{
+			Kind:    errdefs.General,
+			Err:     fmt.Errorf("%d hostname(s) without a healthy record", len(unhealthy)),
+			Details: unhealthy,
+		}
+	}
+	return nil
+}
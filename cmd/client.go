@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/logging"
+	"github.com/etcdhosts/dnsctl/v2/internal/retry"
+)
+
+// hostsClient wraps the client-go client. Calls return as soon as the
+// command is interrupted or times out, and are retried with backoff when
+// etcd is briefly unavailable, e.g. during a leader election.
+//
+// Write is a compare-and-swap on the hosts version, done in one etcd
+// transaction: a concurrent change makes it fail with a conflict. A retry
+// after a lost response finds its own data and succeeds rather than
+// writing twice. ForceWrite replaces the data unconditionally and is not
+// retried, as a retry after a lost response could overwrite another
+// client's write. A write that is interrupted or times out after it was
+// sent fails with errdefs.ErrOutcomeUnknown: it may have been applied.
+//
+// With --log-level debug, calls that go through client-go are logged once
+// each with their key, revision, size and duration. client-go does not
+// report the member that served a call, so the endpoint logged is the
+// configured endpoint list. Writes go through the raw etcd client, whose
+// interceptor logs every RPC with its member, so they are not logged here
+// again. Retries are reported by retryPolicy.
+type hostsClient struct {
+	*client.Client
+
+	// etcd is the raw client for writes, created on first use, and
+	// timeout bounds each of its requests.
+	etcd    *clientv3.Client
+	timeout time.Duration
+
+	// revision is the etcd revision of the last successful write.
+	revision int64
+
+	// endpoints is the redacted endpoint list for the log.
+	endpoints string
+}
+
+// retryPolicy is retry.Default with a warning for each retry.
+var retryPolicy = func() retry.Policy {
+	p := retry.Default
+	p.OnRetry = func(attempt int, err error, wait time.Duration) {
+		fmt.Fprintf(os.Stderr, "Warning: %v, retrying in %s (%d/%d)\n", err, wait.Round(time.Millisecond), attempt, p.Attempts-1)
+	}
+	return p
+}()
+
+// writePolicy is retryPolicy for writes, which stop by themselves when the
+// command is interrupted so that none is left running in the background.
+var writePolicy = func() retry.Policy {
+	p := retryPolicy
+	p.Wait = true
+	return p
+}()
+
+func (c *hostsClient) Read() (*client.Hosts, error) {
+	start := time.Now()
+	h, err := retry.Value(commandContext(), retryPolicy, c.Client.Read)
+	c.log("read", c.Key(), start, err, h)
+	return h, err
+}
+
+func (c *hostsClient) ReadRevision(revision int64) (*client.Hosts, error) {
+	start := time.Now()
+	h, err := retry.Value(commandContext(), retryPolicy, func() (*client.Hosts, error) {
+		return c.Client.ReadRevision(revision)
+	})
+	c.log("read", c.Key(), start, err, h)
+	return h, err
+}
+
+func (c *hostsClient) Write(h *client.Hosts) error {
+	version := h.Version()
+	h.SetModified(time.Now())
+	data := h.String()
+	revision, err := retry.Value(commandContext(), writePolicy, func() (int64, error) {
+		return c.put(data, &version)
+	})
+	if err == nil {
+		c.revision = revision
+	}
+	return err
+}
+
+func (c *hostsClient) ForceWrite(data []byte) error {
+	h, err := client.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse hosts data: %w", err)
+	}
+	h.SetModified(time.Now())
+	data = []byte(h.String())
+	revision, err := c.put(string(data), nil)
+	if err == nil {
+		c.revision = revision
+	}
+	return err
+}
+
+// put stores data under the hosts key in a transaction that, if version
+// is set, only succeeds while the key is still at that version. It holds
+// the lock client-go's Write takes, so it is serialized with writers that
+// use client-go directly. It returns the revision of the write.
+//
+// If the transaction fails because an earlier attempt whose response was
+// lost already stored data, put succeeds with the revision of that attempt.
+// If it is interrupted or times out once the transaction was sent, the
+// error wraps errdefs.ErrOutcomeUnknown.
+func (c *hostsClient) put(data string, version *int64) (int64, error) {
+	mode, err := c.Client.Mode()
+	if err != nil {
+		return 0, err
+	}
+	if mode == client.ModePerHost {
+		return 0, fmt.Errorf("write is not supported in perhost mode")
+	}
+
+	etcd, err := c.etcdClient()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(commandContext(), c.timeout)
+	defer cancel()
+
+	session, err := concurrency.NewSession(etcd, concurrency.WithContext(ctx))
+	if err != nil {
+		return 0, putError(ctx, "failed to create etcd session", err)
+	}
+	defer func() { _ = session.Close() }()
+	mu := concurrency.NewMutex(session, c.Key()+"/lock")
+	if err := mu.Lock(ctx); err != nil {
+		return 0, putError(ctx, "failed to lock etcd key", err)
+	}
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		_ = mu.Unlock(unlockCtx)
+	}()
+
+	key := c.Key()
+	txn := etcd.Txn(ctx)
+	if version != nil {
+		txn = txn.If(clientv3.Compare(clientv3.Version(key), "=", *version))
+	}
+	resp, err := txn.Then(clientv3.OpPut(key, data)).Else(clientv3.OpGet(key)).Commit()
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("%w: %w", errdefs.ErrOutcomeUnknown, putError(ctx, "request aborted", err))
+		}
+		return 0, fmt.Errorf("failed to put hosts: %w", err)
+	}
+	if resp.Succeeded {
+		return resp.Header.Revision, nil
+	}
+
+	var current int64
+	if kvs := resp.Responses[0].GetResponseRange().Kvs; len(kvs) > 0 {
+		if string(kvs[0].Value) == data {
+			return kvs[0].ModRevision, nil
+		}
+		current = kvs[0].Version
+	}
+	return 0, errdefs.Errorf(errdefs.Conflict, "version conflict: current=%d, yours=%d", current, *version)
+}
+
+// putError describes err from a step of put. If the command ended, its
+// cause is returned so that the error keeps its kind.
+func putError(ctx context.Context, msg string, err error) error {
+	if commandContext().Err() != nil {
+		return context.Cause(commandContext())
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// etcdClient returns the raw etcd client writes go through, creating it
+// on first use.
+func (c *hostsClient) etcdClient() (*clientv3.Client, error) {
+	if c.etcd == nil {
+		etcd, _, err := newEtcdClient()
+		if err != nil {
+			return nil, err
+		}
+		c.etcd = etcd
+	}
+	return c.etcd, nil
+}
+
+// Close closes the client-go client and the raw etcd client, if any.
+func (c *hostsClient) Close() error {
+	if c.etcd != nil {
+		_ = c.etcd.Close()
+	}
+	return c.Client.Close()
+}
+
+func (c *hostsClient) History() ([]*client.Hosts, error) {
+	start := time.Now()
+	hs, err := retry.Value(commandContext(), retryPolicy, c.Client.History)
+	c.log("history", c.Key(), start, err, hs...)
+	return hs, err
+}
+
+func (c *hostsClient) HistoryHost(domain string) ([]*client.Hosts, error) {
+	start := time.Now()
+	hs, err := retry.Value(commandContext(), retryPolicy, func() ([]*client.Hosts, error) {
+		return c.Client.HistoryHost(domain)
+	})
+	c.log("history", c.Key()+"/"+domain, start, err, hs...)
+	return hs, err
+}
+
+func (c *hostsClient) ListDomains() ([]string, error) {
+	start := time.Now()
+	domains, err := retry.Value(commandContext(), retryPolicy, c.Client.ListDomains)
+	c.logRequest(logging.Request{Op: "list-domains", Key: c.Key() + "/*"}, start, err)
+	return domains, err
+}
+
+func (c *hostsClient) Mode() (client.StorageMode, error) {
+	start := time.Now()
+	mode, err := retry.Value(commandContext(), retryPolicy, c.Client.Mode)
+	c.logRequest(logging.Request{Op: "mode", Key: c.Key() + "/.meta"}, start, err)
+	return mode, err
+}
+
+// log logs a call that read hosts. The revision is the latest modification
+// revision and the size is that of the hosts data.
+func (c *hostsClient) log(op, key string, start time.Time, err error, hosts ...*client.Hosts) {
+	r := logging.Request{Op: op, Key: key}
+	if err == nil && logger.Enabled(commandContext(), slog.LevelDebug) {
+		for _, h := range hosts {
+			if h == nil {
+				continue
+			}
+			r.Revision = max(r.Revision, h.ModRevision())
+			r.Bytes += len(h.String())
+		}
+	}
+	c.logRequest(r, start, err)
+}
+
+func (c *hostsClient) logRequest(r logging.Request, start time.Time, err error) {
+	r.Endpoint, r.Duration, r.Err = c.endpoints, time.Since(start), err
+	logging.Log(commandContext(), logger, r)
+}
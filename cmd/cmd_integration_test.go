@@ -4,6 +4,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -14,6 +15,8 @@ import (
 
 	client "github.com/etcdhosts/client-go/v2"
 	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/schedule"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -246,3 +249,178 @@ func TestIntegration_KeyLayout(t *testing.T) {
 		t.Errorf("Key = %s, want /dns/records", cli.Key())
 	}
 }
+
+func TestIntegration_Schedule(t *testing.T) {
+	endpoint, cleanup := startEtcd(t)
+	defer cleanup()
+
+	cfgFile = createTestConfig(t, endpoint, "/etcdhosts")
+
+	cli, err := newClient()
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	store, closeStore, err := newScheduleStore()
+	if err != nil {
+		t.Fatalf("newScheduleStore() error = %v", err)
+	}
+	defer closeStore()
+
+	hosts, _ := cli.Read()
+	_ = hosts.Add(client.Record{Hostname: "old.local", IP: net.ParseIP("10.0.0.1")})
+	if err := cli.Write(hosts); err != nil {
+		t.Fatalf("Write error = %v", err)
+	}
+	hosts, _ = cli.Read()
+
+	change := &schedule.Change{
+		At:          time.Now().Add(-time.Second),
+		BaseVersion: hosts.Version(),
+		Records:     1,
+		Content:     "10.0.0.2 new.local\n",
+		Audit:       newAuditEntry("schedule"),
+	}
+	if err := store.Add(context.Background(), change); err != nil {
+		t.Fatalf("Add error = %v", err)
+	}
+	conflict := &schedule.Change{
+		At:          time.Now().Add(-time.Second),
+		BaseVersion: hosts.Version() - 1,
+		Content:     "10.0.0.3 conflict.local\n",
+	}
+	if err := store.Add(context.Background(), conflict); err != nil {
+		t.Fatalf("Add error = %v", err)
+	}
+
+	if err := applyDueChanges(cli, store); err != nil {
+		t.Fatalf("applyDueChanges error = %v", err)
+	}
+
+	hosts, _ = cli.Read()
+	if len(hosts.Lookup("new.local")) != 1 || len(hosts.Lookup("old.local")) != 0 {
+		t.Errorf("scheduled change not applied:\n%s", hosts.String())
+	}
+
+	applied, _ := store.Get(context.Background(), change.ID)
+	if applied.Status != schedule.StatusApplied || applied.Revision != hosts.ModRevision() {
+		t.Errorf("Status = %s, Revision = %d, want applied at %d", applied.Status, applied.Revision, hosts.ModRevision())
+	}
+	failed, _ := store.Get(context.Background(), conflict.ID)
+	if failed.Status != schedule.StatusFailed {
+		t.Errorf("conflicting change Status = %s, want failed", failed.Status)
+	}
+
+	annotations := loadAudit()
+	if e, ok := annotations[hosts.ModRevision()]; !ok || e.Command != "schedule" {
+		t.Errorf("missing audit entry for revision %d: %v", hosts.ModRevision(), annotations)
+	}
+
+	// A worker whose claim was taken over does not overwrite the outcome.
+	ctx := context.Background()
+	late := &schedule.Change{At: time.Now().Add(time.Hour), Content: "10.0.0.4 late.local\n"}
+	if err := store.Add(ctx, late); err != nil {
+		t.Fatalf("Add error = %v", err)
+	}
+	if err := store.Claim(ctx, late); err != nil {
+		t.Fatalf("Claim error = %v", err)
+	}
+	other, _ := store.Get(ctx, late.ID)
+	if err := store.Finish(ctx, other, 0, errors.New("canceled elsewhere")); err != nil {
+		t.Fatalf("Finish error = %v", err)
+	}
+	if err := store.Finish(ctx, late, 42, nil); !errors.Is(err, schedule.ErrClaimLost) {
+		t.Errorf("Finish after losing the claim error = %v, want ErrClaimLost", err)
+	}
+	if got, _ := store.Get(ctx, late.ID); got.Status != schedule.StatusFailed {
+		t.Errorf("Status after a lost claim = %s, want failed", got.Status)
+	}
+
+	dumpKeys(t, endpoint, "/etcdhosts")
+}
+
+func TestIntegration_WriteConflict(t *testing.T) {
+	endpoint, cleanup := startEtcd(t)
+	defer cleanup()
+
+	cfgFile = createTestConfig(t, endpoint, "/etcdhosts")
+
+	cli, err := newClient()
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	stale, _ := cli.Read()
+	hosts, _ := cli.Read()
+	_ = hosts.Add(client.Record{Hostname: "a.local", IP: net.ParseIP("10.0.0.1")})
+	if err := cli.Write(hosts); err != nil {
+		t.Fatalf("Write error = %v", err)
+	}
+
+	// A writer using client-go directly takes the same lock.
+	hosts, _ = cli.Read()
+	_ = hosts.Add(client.Record{Hostname: "b.local", IP: net.ParseIP("10.0.0.2")})
+	if err := cli.Client.Write(hosts); err != nil {
+		t.Fatalf("client-go Write error = %v", err)
+	}
+
+	_ = stale.Add(client.Record{Hostname: "c.local", IP: net.ParseIP("10.0.0.3")})
+	err = cli.Write(stale)
+	if errdefs.KindOf(err) != errdefs.Conflict {
+		t.Fatalf("stale Write error = %v, want a conflict", err)
+	}
+
+	hosts, _ = cli.Read()
+	if len(hosts.Lookup("b.local")) != 1 || len(hosts.Lookup("c.local")) != 0 {
+		t.Errorf("stale write was applied:\n%s", hosts.String())
+	}
+
+	if err := cli.ForceWrite([]byte("10.0.0.4 d.local\n")); err != nil {
+		t.Fatalf("ForceWrite error = %v", err)
+	}
+	hosts, _ = cli.Read()
+	if hosts.Len() != 1 || len(hosts.Lookup("d.local")) != 1 {
+		t.Errorf("ForceWrite result:\n%s", hosts.String())
+	}
+}
+
+func TestIntegration_AuditRevision(t *testing.T) {
+	endpoint, cleanup := startEtcd(t)
+	defer cleanup()
+
+	cfgFile = createTestConfig(t, endpoint, "/etcdhosts")
+
+	cli, err := newClient()
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	hosts, _ := cli.Read()
+	_ = hosts.Add(client.Record{Hostname: "a.local", IP: net.ParseIP("10.0.0.1")})
+	if err := cli.Write(hosts); err != nil {
+		t.Fatalf("Write error = %v", err)
+	}
+	written, _ := cli.Read()
+
+	// Another writer gets in before the annotation is stored.
+	other, _ := cli.Read()
+	_ = other.Add(client.Record{Hostname: "b.local", IP: net.ParseIP("10.0.0.2")})
+	if err := cli.Client.Write(other); err != nil {
+		t.Fatalf("client-go Write error = %v", err)
+	}
+
+	changeMessage = "add a"
+	defer func() { changeMessage = "" }()
+	recordAudit(cli, newAuditEntry("edit"))
+
+	annotations := loadAudit()
+	if e, ok := annotations[written.ModRevision()]; !ok || e.Message != "add a" {
+		t.Errorf("no annotation for revision %d: %v", written.ModRevision(), annotations)
+	}
+	if len(annotations) != 1 {
+		t.Errorf("annotations = %v, want only revision %d", annotations, written.ModRevision())
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+)
+
+// configCmd represents the config command.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage dnsctl configuration and contexts",
+	Long: `Manage dnsctl configuration and contexts.
+
+A config file may define several named contexts, one per etcd cluster:
+
+  current-context: prod
+  contexts:
+    prod:
+      endpoints:
+        - https://10.0.0.1:2379
+      key: /etcdhosts
+      confirm: true      # ask before mutating commands
+    staging:
+      endpoints:
+        - https://10.1.0.1:2379
+
+Use --context to select a context for a single command.
+
+Example:
+  dnsctl config get-contexts
+  dnsctl config use-context staging
+  dnsctl config check
+  dnsctl --context prod list`,
+}
+
+var configUseContextCmd = &cobra.Command{
+	Use:               "use-context NAME",
+	Short:             "Set the current context in the config file",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContexts,
+	RunE:              runConfigUseContext,
+}
+
+var configGetContextsCmd = &cobra.Command{
+	Use:   "get-contexts",
+	Short: "List the contexts in the config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigGetContexts,
+}
+
+var configCurrentContextCmd = &cobra.Command{
+	Use:   "current-context",
+	Short: "Print the context in use",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigCurrentContext,
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the config file for problems",
+	Long: `Check the config file and report all problems at once.
+
+Reports unknown or misspelled fields, invalid endpoint URLs, CA, cert and
+key files that are missing or cannot be parsed, a current-context that
+does not exist, and a password stored in a file readable by other users.
+Every context is checked. Exits non-zero if any error is found; warnings
+alone do not fail.
+
+Example:
+  dnsctl config check
+  dnsctl -c /etc/dnsctl.yaml config check`,
+	Args: cobra.NoArgs,
+	RunE: runConfigCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configUseContextCmd, configGetContextsCmd, configCurrentContextCmd, configCheckCmd)
+}
+
+func runConfigUseContext(cmd *cobra.Command, args []string) error {
+	if err := config.SetCurrentContext(cfgFile, args[0]); err != nil {
+		return errdefs.Wrap(errdefs.Config, err)
+	}
+	fmt.Printf("Switched to context %q.\n", args[0])
+	return nil
+}
+
+func runConfigGetContexts(cmd *cobra.Command, args []string) error {
+	f, err := config.ReadFile(cfgFile)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Config, err)
+	}
+
+	if len(f.Contexts) == 0 {
+		fmt.Printf("No contexts defined in %s.\n", cfgFile)
+		return nil
+	}
+
+	current := currentContext(f)
+	fmt.Printf("%-7s  %-16s  %-20s  %-7s  %s\n", "CURRENT", "NAME", "KEY", "CONFIRM", "ENDPOINTS")
+	for _, name := range f.ContextNames() {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+
+		cfg, err := f.Resolve(name)
+		if err != nil {
+			return errdefs.Wrap(errdefs.Config, err)
+		}
+		confirm := "no"
+		if cfg.Confirm {
+			confirm = "yes"
+		}
+		fmt.Printf("%-7s  %-16s  %-20s  %-7s  %s\n",
+			marker, name, cfg.Key, confirm, strings.Join(cfg.Endpoints, ","))
+	}
+	return nil
+}
+
+func runConfigCurrentContext(cmd *cobra.Command, args []string) error {
+	f, err := config.ReadFile(cfgFile)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Config, err)
+	}
+
+	current := currentContext(f)
+	if current == "" {
+		return errdefs.New(errdefs.Config, "current-context is not set")
+	}
+	fmt.Println(current)
+	return nil
+}
+
+func runConfigCheck(cmd *cobra.Command, args []string) error {
+	problems, err := config.Check(cfgFile)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Config, err)
+	}
+
+	if len(problems) == 0 {
+		fmt.Printf("%s: OK\n", cfgFile)
+		return nil
+	}
+
+	errs, warns := 0, 0
+	fmt.Printf("%s:\n", cfgFile)
+	for _, p := range problems {
+		level := "error"
+		if p.Warning {
+			level = "warning"
+			warns++
+		} else {
+			errs++
+		}
+		fmt.Printf("  %-8s %s\n", level, p)
+	}
+	fmt.Printf("Found %d error(s), %d warning(s).\n", errs, warns)
+
+	if errs > 0 {
+		return errdefs.Errorf(errdefs.Config, "%s has %d error(s)", cfgFile, errs)
+	}
+	return nil
+}
+
+// currentContext returns the context in use: --context, or the file's
+// current-context.
+func currentContext(f *config.File) string {
+	if cfgContext != "" {
+		return cfgContext
+	}
+	return f.CurrentContext
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/logging"
+)
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a config file or add a context interactively",
+	Long: `Create a config file or add a context to it interactively.
+
+Asks for the etcd endpoints, key, TLS files and authentication, tests the
+connection by reading the key and shows the detected storage mode. A new
+config file is written with mode 0600. If the config file exists, the
+answers are added to it as a new context; top-level settings of a file
+without contexts are moved to a context named "default".
+
+Example:
+  dnsctl config init
+  dnsctl -c ./staging.yaml config init`,
+	Args: cobra.NoArgs,
+	RunE: runConfigInit,
+}
+
+func init() {
+	configCmd.AddCommand(configInitCmd)
+}
+
+// prompter asks the questions of the config wizard.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask returns the answer to question, or def if the answer is empty.
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("no answer to %q: %w", question, err)
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+// confirm asks a yes/no question.
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s (%s)", question, hint), "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+
+	existing, err := config.ReadFile(cfgFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errdefs.Wrap(errdefs.Config, err)
+	}
+
+	var name string
+	if existing != nil {
+		fmt.Printf("Adding a context to %s.\n", cfgFile)
+		if name, err = askContextName(p, existing); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Creating %s.\n", cfgFile)
+		if name, err = p.ask("Context name (empty for a single cluster)", ""); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := askConfig(p)
+	if err != nil {
+		return err
+	}
+
+	save := true
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("The config has problems:\n%v\n", err)
+		save = false
+	} else if test, err := p.confirm("Test the connection now?", true); err != nil {
+		return err
+	} else if test {
+		if err := testConnection(cfg); err != nil {
+			fmt.Printf("Connection failed: %v\n", err)
+			save = false
+		}
+	}
+	if !save {
+		if save, err = p.confirm("Save anyway?", false); err != nil {
+			return err
+		}
+		if !save {
+			return errors.New("aborted")
+		}
+	}
+
+	if existing == nil {
+		f := &config.File{Config: *cfg}
+		if name != "" {
+			f = &config.File{CurrentContext: name, Contexts: map[string]*config.Config{name: cfg}}
+		}
+		if err := config.Create(cfgFile, f); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s.\n", cfgFile)
+		return nil
+	}
+
+	if err := config.AddContext(cfgFile, name, cfg); err != nil {
+		return err
+	}
+	if len(existing.Contexts) == 0 && len(existing.Endpoints) > 0 {
+		fmt.Printf("Moved the existing settings to context %q.\n", config.DefaultContext)
+	}
+	fmt.Printf("Added context %q to %s.\n", name, cfgFile)
+
+	f, err := config.ReadFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	if f.CurrentContext == name {
+		return nil
+	}
+	use, err := p.confirm(fmt.Sprintf("Switch to context %q?", name), false)
+	if err != nil || !use {
+		return err
+	}
+	if err := config.SetCurrentContext(cfgFile, name); err != nil {
+		return err
+	}
+	fmt.Printf("Switched to context %q.\n", name)
+	return nil
+}
+
+// askContextName asks for the name of a context that is not in f yet.
+func askContextName(p *prompter, f *config.File) (string, error) {
+	legacy := len(f.Contexts) == 0 && len(f.Endpoints) > 0
+	for {
+		name, err := p.ask("Context name", "")
+		if err != nil {
+			return "", err
+		}
+		switch _, exists := f.Contexts[name]; {
+		case name == "":
+			fmt.Println("A context name is required.")
+		case exists:
+			fmt.Printf("Context %q already exists.\n", name)
+		case legacy && name == config.DefaultContext:
+			fmt.Printf("Context %q is used for the existing settings.\n", name)
+		default:
+			return name, nil
+		}
+	}
+}
+
+// askConfig asks for the connection settings.
+func askConfig(p *prompter) (*config.Config, error) {
+	cfg := &config.Config{}
+
+	for {
+		answer, err := p.ask("etcd endpoints, comma separated", "http://127.0.0.1:2379")
+		if err != nil {
+			return nil, err
+		}
+		cfg.Endpoints = nil
+		var invalid []string
+		for _, ep := range strings.Split(answer, ",") {
+			if ep = strings.TrimSpace(ep); ep == "" {
+				continue
+			}
+			if err := config.CheckEndpoint(ep); err != nil {
+				invalid = append(invalid, err.Error())
+			}
+			cfg.Endpoints = append(cfg.Endpoints, ep)
+		}
+		if len(invalid) == 0 && len(cfg.Endpoints) > 0 {
+			break
+		}
+		fmt.Println(strings.Join(append(invalid, "Please enter at least one valid endpoint."), "\n"))
+	}
+
+	var err error
+	if cfg.Key, err = p.ask("Key", "/etcdhosts"); err != nil {
+		return nil, err
+	}
+
+	https := false
+	for _, ep := range cfg.Endpoints {
+		https = https || strings.HasPrefix(ep, "https://")
+	}
+	useTLS, err := p.confirm("Configure TLS (CA and client certificate)?", https)
+	if err != nil {
+		return nil, err
+	}
+	if useTLS {
+		if cfg.CA, err = p.ask("CA file (empty for system roots)", ""); err != nil {
+			return nil, err
+		}
+		if cfg.Cert, err = p.ask("Client certificate file (empty for none)", ""); err != nil {
+			return nil, err
+		}
+		if cfg.Cert != "" {
+			if cfg.CertKey, err = p.ask("Client key file", ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if cfg.Username, err = p.ask("Username (empty for no authentication)", ""); err != nil {
+		return nil, err
+	}
+	if cfg.Username != "" {
+		if err := askPassword(p, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Confirm, err = p.confirm("Ask for confirmation before changes (recommended for production)?", false); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// askPassword asks where the password comes from.
+func askPassword(p *prompter, cfg *config.Config) error {
+	for {
+		source, err := p.ask("Password source: prompt, env, file, cmd or plain", "prompt")
+		if err != nil {
+			return err
+		}
+		switch source {
+		case "prompt":
+			return nil
+		case "env":
+			cfg.PasswordEnv, err = p.ask("Environment variable", "ETCD_PASSWORD")
+			return err
+		case "file":
+			cfg.PasswordFile, err = p.ask("Password file", "")
+			return err
+		case "cmd":
+			cfg.PasswordCmd, err = p.ask("Password command, e.g. pass show etcd", "")
+			return err
+		case "plain":
+			cfg.Password, err = promptPassword(cfg.Username)
+			return err
+		}
+		fmt.Printf("Unknown password source %q.\n", source)
+	}
+}
+
+// testConnection reads the key with cfg and reports the storage mode.
+func testConnection(cfg *config.Config) error {
+	c, err := (&config.File{Config: *cfg}).Resolve("")
+	if err != nil {
+		return err
+	}
+	if err := c.ResolvePassword(promptPassword); err != nil {
+		return err
+	}
+
+	etcd, err := client.NewClient(c.ToClientConfig())
+	if err != nil {
+		return err
+	}
+	cli := &hostsClient{Client: etcd, endpoints: logging.RedactURLs(c.Endpoints)}
+	defer func() { _ = cli.Close() }()
+
+	mode, err := cli.Mode()
+	if err != nil {
+		return err
+	}
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+
+	if hosts.Len() == 0 {
+		fmt.Printf("Connected: key %s has no records yet (%s mode).\n", c.Key, mode)
+		return nil
+	}
+	fmt.Printf("Connected: key %s has %d records in %s mode.\n", c.Key, hosts.Len(), mode)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/etcdhosts/dnsctl/v2/internal/diff"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
 )
 
 // diffCmd represents the diff command.
@@ -31,10 +32,10 @@ func init() {
 func runDiff(cmd *cobra.Command, args []string) error {
 	var rev1, rev2 int64
 	if _, err := fmt.Sscanf(args[0], "%d", &rev1); err != nil {
-		return fmt.Errorf("invalid revision: %s", args[0])
+		return errdefs.Errorf(errdefs.Usage, "invalid revision: %s", args[0])
 	}
 	if _, err := fmt.Sscanf(args[1], "%d", &rev2); err != nil {
-		return fmt.Errorf("invalid revision: %s", args[1])
+		return errdefs.Errorf(errdefs.Usage, "invalid revision: %s", args[1])
 	}
 
 	cli, err := newClient()
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/doctor"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+var doctorOutput string
+
+// doctorCmd represents the doctor command.
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the etcd connection and the stored hosts data",
+	Long: `Diagnose the etcd connection and the stored hosts data.
+
+Each endpoint is checked on its own:
+  reachable  TCP connection and connect time
+  tls        TLS handshake and server certificate expiry (https only)
+  auth       authentication (when a username is set)
+  latency    duration of a status request
+  version    etcd version and database size
+  leader     whether the cluster has a leader
+
+Then the hosts data is checked:
+  key        the hosts key exists
+  mode       storage mode (single or perhost)
+  data       strict parsing of the stored data, listing invalid lines
+
+Exits non-zero if any check fails. Warnings, e.g. a certificate that
+expires within 30 days, do not fail.
+
+` + output.FormatHelp(output.DataFormats, nil) + `
+Example:
+  dnsctl doctor
+  dnsctl --context prod doctor -o json`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(doctorOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	ctx := commandContext()
+
+	results := doctor.ClientCert(cfg)
+
+	perEndpoint := make([][]doctor.Result, len(cfg.Endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range cfg.Endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			perEndpoint[i] = doctor.Endpoint(ctx, cfg, ep)
+		}()
+	}
+	wg.Wait()
+	for _, r := range perEndpoint {
+		results = append(results, r...)
+	}
+
+	results = append(results, checkData(ctx, cfg.Key)...)
+
+	if format == output.FormatTable {
+		printDoctorTable(results)
+	} else if err := output.Print(doctor.Results(results), format); err != nil {
+		return err
+	}
+
+	if failed := doctor.Count(results)[doctor.StatusFail]; failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// checkData runs the data checks through a client for all endpoints.
+func checkData(ctx context.Context, key string) []doctor.Result {
+	etcd, cfg, err := newEtcdClient()
+	if err != nil {
+		return []doctor.Result{{Check: "key", Target: key, Status: doctor.StatusFail, Detail: err.Error()}}
+	}
+	defer func() { _ = etcd.Close() }()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ReqTimeout)
+	defer cancel()
+	return doctor.Data(ctx, etcd, cfg.Key)
+}
+
+// printDoctorTable prints diagnostic results with a summary line.
+// Invalid data lines are listed below their check.
+func printDoctorTable(results []doctor.Result) {
+	fmt.Printf("%-6s  %-12s  %-32s  %s\n", "STATUS", "CHECK", "TARGET", "DETAIL")
+	fmt.Println("------  ------------  --------------------------------  --------------------")
+
+	for _, r := range results {
+		fmt.Printf("%-6s  %-12s  %-32s  %s\n", strings.ToUpper(string(r.Status)), r.Check, r.Target, r.Detail)
+		for _, line := range r.Lines {
+			fmt.Printf("%-6s  %-12s  %-32s    %s\n", "", "", "", line)
+		}
+	}
+
+	counts := doctor.Count(results)
+	fmt.Printf("\n%d passed, %d warning(s), %d failed\n",
+		counts[doctor.StatusPass], counts[doctor.StatusWarn], counts[doctor.StatusFail])
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/etcdhosts/dnsctl/v2/internal/editor"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
 	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
 )
 
 // editCmd represents the edit command.
@@ -24,15 +26,25 @@ The editor is determined by:
 
 Example:
   dnsctl edit
+  dnsctl edit -m "move api to rack 2"
+  dnsctl edit --verify-dns 10.0.0.53:53
   EDITOR=nano dnsctl edit`,
 	RunE: runEdit,
 }
 
 func init() {
 	rootCmd.AddCommand(editCmd)
+
+	addMessageFlag(editCmd)
+	addVerifyFlags(editCmd)
 }
 
 func runEdit(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	cli, err := newClient()
 	if err != nil {
 		return err
@@ -44,7 +56,12 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	result, err := editor.Edit(hosts.String())
+	content := hosts.String()
+	if cfg.Name != "" {
+		content = fmt.Sprintf("# dnsctl: editing %s\n", cfg.Describe()) + content
+	}
+
+	result, err := editor.Edit(content)
 	if err != nil {
 		return err
 	}
@@ -57,12 +74,7 @@ func runEdit(cmd *cobra.Command, args []string) error {
 	// Use strict parsing to detect errors
 	parseResult := client.ParseRecordsStrict(result.Content)
 	if parseResult.HasErrors() {
-		fmt.Printf("Error: found %d invalid record(s):\n", len(parseResult.Errors))
-		for _, e := range parseResult.Errors {
-			fmt.Printf("  - %s\n", e.String())
-		}
-		fmt.Println("\nNo changes were saved. Please fix the errors and try again.")
-		return nil
+		return invalidRecordsError(parseResult.Errors, ", no changes were saved")
 	}
 
 	newHosts, warnings := dedupeRecords(parseResult.Records)
@@ -74,22 +86,45 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if err := confirmTarget(fmt.Sprintf("Save %d records", newHosts.Len())); err != nil {
+		return err
+	}
+
 	newHosts.SetModified(hosts.Modified())
 	if err := cli.ForceWrite([]byte(newHosts.String())); err != nil {
 		return err
 	}
+	recordAudit(cli, newAuditEntry("edit"))
 
 	fmt.Printf("Updated %d records.\n", newHosts.Len())
-	return nil
+	return verifyWrite(hosts.Records(), newHosts.Records())
+}
+
+// invalidRecordsError returns a validation error listing the invalid lines
+// of a hosts file. The suffix is appended to the message.
+func invalidRecordsError(errs []client.ParseError, suffix string) error {
+	details := make([]string, len(errs))
+	for i, e := range errs {
+		details[i] = e.String()
+	}
+	return &errdefs.Error{
+		Kind:    errdefs.Validation,
+		Err:     fmt.Errorf("found %d invalid record(s)%s", len(errs), suffix),
+		Details: details,
+	}
 }
 
-// dedupeRecords creates a new Hosts with duplicates removed.
+// dedupeRecords creates a new Hosts with duplicates removed. Records are
+// canonicalized so that records added here look like those read back from
+// etcd; client-go already compares IPs by value and stores them in
+// canonical text form, so this does not change which records are kept.
 // Returns the deduplicated Hosts and warning messages for removed duplicates.
-func dedupeRecords(records []client.Record) (*client.Hosts, []string) {
+func dedupeRecords(recs []client.Record) (*client.Hosts, []string) {
 	newHosts := client.NewHosts()
 	var warnings []string
 
-	for _, r := range records {
+	for _, r := range recs {
+		r = records.Canonicalize(r)
 		if err := newHosts.Add(r); err != nil {
 			if errors.Is(err, client.ErrDuplicateRecord) {
 				warnings = append(warnings,
@@ -100,3 +135,14 @@ func dedupeRecords(records []client.Record) (*client.Hosts, []string) {
 
 	return newHosts, warnings
 }
+
+// replaceRecords replaces all records in hosts with records, keeping the
+// version read from etcd so that Write still performs its version check.
+func replaceRecords(hosts *client.Hosts, recs []client.Record) {
+	for _, r := range hosts.Records() {
+		hosts.Purge(r.Hostname)
+	}
+	for _, r := range recs {
+		_ = hosts.Add(records.Canonicalize(r))
+	}
+}
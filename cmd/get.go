@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+var (
+	getOutput   string
+	getRevision int64
+	getFallback bool
+)
+
+// getCmd represents the get command.
+var getCmd = &cobra.Command{
+	Use:   "get HOSTNAME",
+	Short: "Show what a hostname resolves to",
+	Long: `Show the records of a hostname with the share of the traffic each IP
+gets by weight, separately for A (IPv4) and AAAA (IPv6) answers.
+
+With --fallback, a hostname without records shows the records of the
+closest name above it that has any: at each level first the wildcard
+(*.example.com for api.example.com), then the parent (example.com).
+The exit code is 8 if nothing matches.
+
+` + output.FormatHelp(output.DataFormats, map[output.Format]string{
+		output.FormatWide: "table with the address family and the kind of match",
+	}) + `
+Example:
+  dnsctl get api.example.com
+  dnsctl get new.example.com --fallback
+  dnsctl get api.example.com -o json
+  dnsctl get api.example.com -r 12345`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGet,
+}
+
+func init() {
+	rootCmd.AddCommand(getCmd)
+
+	getCmd.Flags().StringVarP(&getOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+	getCmd.Flags().Int64VarP(&getRevision, "revision", "r", 0, "read from specific etcd revision")
+	getCmd.Flags().BoolVar(&getFallback, "fallback", false, "show wildcard or parent records if the hostname has none")
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(getOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	var hosts *client.Hosts
+	if getRevision > 0 {
+		hosts, err = cli.ReadRevision(getRevision)
+	} else {
+		hosts, err = cli.Read()
+	}
+	if err != nil {
+		return err
+	}
+
+	res := records.Resolve(hosts.Records(), args[0], getFallback)
+	if res.Match == "" {
+		return errdefs.Errorf(errdefs.NotFound, "no records for %s", res.Hostname)
+	}
+
+	if format == output.FormatTable && res.Match != records.MatchExact {
+		fmt.Printf("No records for %s, matched by %s %s:\n\n", res.Hostname, res.Match, res.Name)
+	}
+	return output.Print(res, format)
+}
@@ -3,14 +3,31 @@ package cmd
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	client "github.com/etcdhosts/client-go/v2"
 	"github.com/spf13/cobra"
 
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/history"
 	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/timeutil"
 )
 
-var historyLimit int
+var (
+	historyLimit  int
+	historySince  string
+	historyUntil  string
+	historyOutput string
+	historyAll    bool
+
+	historyFormat output.Format
+)
+
+// historyWorkers limits concurrent HistoryHost requests for --all and
+// 'audit-dns' in per-host mode.
+const historyWorkers = 8
 
 // historyCmd represents the history command.
 var historyCmd = &cobra.Command{
@@ -24,13 +41,30 @@ For single-key mode:
 For per-host mode:
   Without argument: lists all domains
   With domain argument: shows history for that domain
+  With --all: shows a single timeline of all domains, ordered by
+              revision, with the domain changed in each revision.
+              Only domains that still exist are included: the history
+              of a deleted domain is not shown.
+
+Each version shows the number of records added (+), removed (-) and
+changed (~) compared to the previous version. Changes made with
+'dnsctl edit', 'purge' or 'schedule' show who made them, the dnsctl
+version and the message given with -m.
+
+--since and --until accept a time (2026-11-01T02:00Z, 2026-11-01 02:00)
+or a duration meaning that long ago (24h, 30m).
 
+` + output.FormatHelp(output.DataFormats, nil) + `
 Use 'dnsctl list -r REVISION' to view a specific version.
 
 Example:
   dnsctl history
   dnsctl history -n 10
-  dnsctl history example.com`,
+  dnsctl history --since 24h
+  dnsctl history --since 2026-11-01 --until 2026-11-02 -o json
+  dnsctl history -o go-template='{{range .}}{{.Revision}} {{.Author}}{{"\n"}}{{end}}'
+  dnsctl history example.com
+  dnsctl history --all --since 24h`,
 	RunE: runHistory,
 }
 
@@ -38,9 +72,31 @@ func init() {
 	rootCmd.AddCommand(historyCmd)
 
 	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "limit number of versions to show (0 = all)")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only show versions modified at or after this time")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "only show versions modified at or before this time")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+	historyCmd.Flags().BoolVarP(&historyAll, "all", "a", false, "show a timeline of all domains (per-host mode)")
 }
 
 func runHistory(cmd *cobra.Command, args []string) error {
+	var err error
+	if historyFormat, err = output.ParseFormat(historyOutput, output.DataFormats); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var since, until time.Time
+	if historySince != "" {
+		if since, err = timeutil.ParseSince(historySince, now); err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+	}
+	if historyUntil != "" {
+		if until, err = timeutil.ParseSince(historyUntil, now); err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+	}
+
 	cli, err := newClient()
 	if err != nil {
 		return err
@@ -53,21 +109,38 @@ func runHistory(cmd *cobra.Command, args []string) error {
 	}
 
 	if mode == client.ModePerHost {
+		if historyAll {
+			if len(args) > 0 {
+				return errdefs.New(errdefs.Usage, "--all cannot be used with a domain argument")
+			}
+			return showTimeline(cli, since, until)
+		}
 		if len(args) == 0 {
 			return listDomains(cli)
 		}
-		return showDomainHistory(cli, args[0])
+		return showDomainHistory(cli, args[0], since, until)
 	}
 
-	return showHistory(cli)
+	if historyAll {
+		return errdefs.New(errdefs.Usage, "--all requires per-host mode")
+	}
+	return showHistory(cli, since, until)
 }
 
-func listDomains(cli *client.Client) error {
+func listDomains(cli *hostsClient) error {
 	domains, err := cli.ListDomains()
 	if err != nil {
 		return err
 	}
 
+	if isStructuredOutput() {
+		names := make([]string, 0, len(domains))
+		for _, d := range domains {
+			names = append(names, strings.TrimSuffix(d, "."))
+		}
+		return output.Print(names, historyFormat)
+	}
+
 	if len(domains) == 0 {
 		fmt.Println("No domains found.")
 		return nil
@@ -82,33 +155,190 @@ func listDomains(cli *client.Client) error {
 	return nil
 }
 
-func showDomainHistory(cli *client.Client, domain string) error {
-	history, err := cli.HistoryHost(domain)
+func showDomainHistory(cli *hostsClient, domain string, since, until time.Time) error {
+	versions, err := cli.HistoryHost(domain)
 	if err != nil {
 		return err
 	}
 
-	if len(history) == 0 {
+	if len(versions) == 0 && !isStructuredOutput() {
 		fmt.Printf("No history for domain: %s\n", domain)
 		return nil
 	}
 
-	fmt.Printf("History for %s:\n\n", domain)
-	output.PrintHistoryTable(history, historyLimit)
-	return nil
+	if !isStructuredOutput() {
+		fmt.Printf("History for %s:\n\n", domain)
+	}
+	return printHistory(versions, since, until)
+}
+
+func showHistory(cli *hostsClient, since, until time.Time) error {
+	versions, err := cli.History()
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 && !isStructuredOutput() {
+		fmt.Println("No history available.")
+		return nil
+	}
+
+	return printHistory(versions, since, until)
 }
 
-func showHistory(cli *client.Client) error {
-	history, err := cli.History()
+// showTimeline merges the history of every domain into one timeline.
+func showTimeline(cli *hostsClient, since, until time.Time) error {
+	domains, err := cli.ListDomains()
 	if err != nil {
 		return err
 	}
 
-	if len(history) == 0 {
+	versions, err := fetchDomainHistories(cli, domains)
+	if err != nil {
+		return err
+	}
+
+	annotations := loadAudit()
+	perDomain := make(map[string][]history.Entry, len(versions))
+	total := 0
+	for domain, v := range versions {
+		perDomain[domain] = history.Build(v, annotations)
+		total += len(v)
+	}
+
+	entries := history.Filter(history.Merge(perDomain), since, until)
+	if historyLimit > 0 && historyLimit < len(entries) {
+		entries = entries[:historyLimit]
+	}
+
+	if isStructuredOutput() {
+		return output.Print(history.Entries(entries), historyFormat)
+	}
+
+	if total == 0 {
 		fmt.Println("No history available.")
 		return nil
 	}
+	printTimelineTable(entries, total)
+	return nil
+}
+
+// fetchDomainHistories fetches the history of each domain concurrently.
+func fetchDomainHistories(cli *hostsClient, domains []string) (map[string][]*client.Hosts, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	versions := make(map[string][]*client.Hosts, len(domains))
+	sem := make(chan struct{}, historyWorkers)
+
+	for _, d := range domains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(domain string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			h, err := cli.HistoryHost(domain)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to get history of %s: %w", domain, err)
+				}
+				return
+			}
+			versions[domain] = h
+		}(d)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return versions, nil
+}
+
+// printHistory summarizes, filters and prints versions ordered newest first.
+func printHistory(versions []*client.Hosts, since, until time.Time) error {
+	entries := history.Build(versions, loadAudit())
+	entries = history.Filter(entries, since, until)
+	if historyLimit > 0 && historyLimit < len(entries) {
+		entries = entries[:historyLimit]
+	}
 
-	output.PrintHistoryTable(history, historyLimit)
+	if isStructuredOutput() {
+		return output.Print(history.Entries(entries), historyFormat)
+	}
+
+	printHistoryTable(entries, len(versions))
 	return nil
 }
+
+// isStructuredOutput reports whether history is printed in a format other
+// than the default table, without headings and hints.
+func isStructuredOutput() bool {
+	return historyFormat != output.FormatTable
+}
+
+// printHistoryTable prints a table of hosts history.
+// Total is the number of versions before filtering and limiting.
+func printHistoryTable(entries []history.Entry, total int) {
+	fmt.Printf("%-14s  %-10s  %-8s  %-14s  %-19s  %-24s  %-10s  %s\n",
+		"REVISION", "VERSION", "RECORDS", "CHANGES", "MODIFIED", "AUTHOR", "DNSCTL", "MESSAGE")
+	fmt.Println("--------------  ----------  --------  --------------  -------------------  ------------------------  ----------  --------------------")
+
+	for _, e := range entries {
+		marker := ""
+		if e.Latest {
+			marker = " (latest)"
+		}
+
+		fmt.Printf("%-14d  %-10d  %-8d  %-14s  %-19s  %-24s  %-10s  %s%s\n",
+			e.Revision,
+			e.Version,
+			e.Records,
+			output.FormatChanges(e.Changes),
+			output.FormatTime(e.Modified),
+			output.OrDash(e.Author),
+			output.OrDash(e.Dnsctl),
+			output.OrDash(e.Message),
+			marker,
+		)
+	}
+
+	fmt.Printf("\nShowing %d of %d versions\n", len(entries), total)
+	fmt.Println("Use 'dnsctl list -r REVISION' to view a specific version.")
+}
+
+// printTimelineTable prints a history timeline merged across domains.
+// Total is the number of versions before filtering and limiting.
+func printTimelineTable(entries []history.Entry, total int) {
+	fmt.Printf("%-14s  %-32s  %-10s  %-8s  %-14s  %-19s  %-24s  %s\n",
+		"REVISION", "DOMAIN", "VERSION", "RECORDS", "CHANGES", "MODIFIED", "AUTHOR", "MESSAGE")
+	fmt.Println("--------------  --------------------------------  ----------  --------  --------------  -------------------  ------------------------  --------------------")
+
+	for _, e := range entries {
+		marker := ""
+		if e.Latest {
+			marker = " (latest)"
+		}
+
+		fmt.Printf("%-14d  %-32s  %-10d  %-8d  %-14s  %-19s  %-24s  %s%s\n",
+			e.Revision,
+			e.Domain,
+			e.Version,
+			e.Records,
+			output.FormatChanges(e.Changes),
+			output.FormatTime(e.Modified),
+			output.OrDash(e.Author),
+			output.OrDash(e.Message),
+			marker,
+		)
+	}
+
+	fmt.Printf("\nShowing %d of %d versions\n", len(entries), total)
+	fmt.Println("Use 'dnsctl history <domain>' to view a single domain.")
+}
@@ -1,49 +1,150 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
 	"github.com/spf13/cobra"
 
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
 	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+var (
+	listOutput   string
+	listRevision int64
+	listIPs      []string
+	listHasHC    bool
+	listWeightGT int
+	listTTL      uint32
+	listSort     string
+	listCount    bool
+	listStack    string
 )
 
-var listOutput string
-var listRevision int64
+// listFormats are the output formats of list.
+var listFormats = append([]output.Format{output.FormatHosts}, output.DataFormats...)
 
 // listCmd represents the list command.
 var listCmd = &cobra.Command{
-	Use:     "list",
+	Use:     "list [HOSTNAME|GLOB...]",
 	Aliases: []string{"ls"},
-	Short:   "List all DNS records",
-	Long: `List all DNS records stored in etcd.
+	Short:   "List DNS records",
+	Long: `List the DNS records stored in etcd.
 
-Output formats:
-  hosts - hosts file format (default)
-  json  - JSON format
-  yaml  - YAML format
+Without arguments or filters, all records are listed. Arguments select
+hostnames, exactly or by glob (*.example.com); the trailing dot and case
+do not matter. A record is listed if it matches any of the hostnames and
+any of the --ip networks, and every other filter. Filters and --sort
+work the same on the current data and on a revision read with -r.
 
+Stacks select hostnames by the address families of all their records:
+  v4     - hostnames with IPv4 records
+  v6     - hostnames with IPv6 records
+  dual   - hostnames with both IPv4 and IPv6 records
+  v4only - hostnames with IPv4 but no IPv6 records
+
+Sort orders:
+  hostname - by hostname, then IP
+  ip       - by IP, IPv4 before IPv6 and numerically (10.0.0.9 before 10.0.0.10)
+  weight   - by weight, heaviest first, then hostname and IP
+
+` + output.FormatHelp(listFormats, map[output.Format]string{
+		output.FormatHosts:  "hosts file format (default)",
+		output.FormatTable:  "HOSTNAME, IP, WEIGHT, TTL and HEALTH columns",
+		output.FormatWide:   "table with the address family and the weight share",
+		output.FormatNDJSON: "one JSON object per record",
+	}) + `
 Example:
   dnsctl list
+  dnsctl list -o table
   dnsctl list -o json
-  dnsctl list -o yaml
-  dnsctl list -r 12345`,
+  dnsctl list -o csv > records.csv
+  dnsctl list -o go-template='{{range .Records}}{{.IP}}{{"\n"}}{{end}}'
+  dnsctl list -o jsonpath='{.records[*].hostname}'
+  dnsctl list -r 12345
+  dnsctl list '*.example.com' --sort ip
+  dnsctl list --ip 10.0.0.0/8 --ip 2001:db8::/32
+  dnsctl list --has-hc --weight-gt 1 -o table
+  dnsctl list --ttl 300 --count
+  dnsctl list --stack v4only -o table`,
+	Args: cobra.ArbitraryArgs,
 	RunE: runList,
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 
-	listCmd.Flags().StringVarP(&listOutput, "output", "o", "hosts", "output format: hosts, json, yaml")
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "hosts", "output format: "+output.FormatList(listFormats))
 	listCmd.Flags().Int64VarP(&listRevision, "revision", "r", 0, "read from specific etcd revision")
+	listCmd.Flags().StringSliceVar(&listIPs, "ip", nil, "only records with an IP in this CIDR or equal to this IP (repeatable)")
+	listCmd.Flags().BoolVar(&listHasHC, "has-hc", false, "only records with a health check")
+	listCmd.Flags().IntVar(&listWeightGT, "weight-gt", 0, "only records with a weight greater than this")
+	listCmd.Flags().Uint32Var(&listTTL, "ttl", 0, "only records with this TTL (0 for records without one)")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "sort order: hostname, ip, weight")
+	listCmd.Flags().BoolVar(&listCount, "count", false, "print only the number of matching records")
+	listCmd.Flags().StringVar(&listStack, "stack", "", "only hostnames in this address family stack: "+strings.Join(records.StackNames, ", "))
+}
+
+// listFilter builds the record filter from the arguments and flags of list.
+func listFilter(cmd *cobra.Command, args []string) (records.Filter, error) {
+	var f records.Filter
+	for _, arg := range args {
+		p, err := records.ParsePattern(arg)
+		if err != nil {
+			return f, errdefs.Wrap(errdefs.Usage, err)
+		}
+		f.Patterns = append(f.Patterns, p)
+	}
+	for _, s := range listIPs {
+		n, err := records.ParseNetwork(s)
+		if err != nil {
+			return f, errdefs.Wrap(errdefs.Usage, err)
+		}
+		f.Networks = append(f.Networks, n)
+	}
+	f.HasHealth = listHasHC
+	if cmd.Flags().Changed("weight-gt") {
+		f.WeightGT = &listWeightGT
+	}
+	if cmd.Flags().Changed("ttl") {
+		f.TTL = &listTTL
+	}
+	if listStack != "" {
+		stack, err := records.ParseStack(listStack)
+		if err != nil {
+			return f, errdefs.Wrap(errdefs.Usage, err)
+		}
+		f.Stack = stack
+	}
+	return f, nil
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(listOutput, listFormats)
+	if err != nil {
+		return err
+	}
+	filter, err := listFilter(cmd, args)
+	if err != nil {
+		return err
+	}
+	if listSort != "" {
+		// Checked before connecting; the records are sorted below.
+		if err := records.Sort(nil, listSort); err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+	}
+
 	cli, err := newClient()
 	if err != nil {
 		return err
 	}
 	defer func() { _ = cli.Close() }()
 
-	var hosts any
+	var hosts *client.Hosts
 	if listRevision > 0 {
 		hosts, err = cli.ReadRevision(listRevision)
 	} else {
@@ -53,5 +154,13 @@ func runList(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return output.Print(hosts, output.Format(listOutput))
+	recs := filter.Apply(hosts.Records())
+	if listCount {
+		fmt.Println(len(recs))
+		return nil
+	}
+	if listSort != "" {
+		_ = records.Sort(recs, listSort)
+	}
+	return output.Print(output.NewRecordList(hosts, recs), format)
 }
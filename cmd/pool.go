@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/pool"
+)
+
+var poolOutput string
+
+// poolCmd represents the pool command.
+var poolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "Inspect the address pools of the config",
+	Long: `Inspect the address pools that 'dnsctl alloc' allocates from.
+
+Pools are defined under pools: in the config:
+
+  pools:
+    lab:
+      cidr: 10.20.0.0/24
+      exclude: [10.20.0.1, 10.20.0.240/28]
+
+Example:
+  dnsctl pool usage
+  dnsctl pool usage lab -o json`,
+}
+
+var poolUsageCmd = &cobra.Command{
+	Use:   "usage [POOL...]",
+	Short: "Show how many addresses of each pool are used",
+	Long: `Show the size, used and free addresses of each pool, or of the given
+pools, and the address 'dnsctl alloc' picks next.
+
+An address counts as used if any record has it, whether or not it was
+allocated by 'dnsctl alloc'. Excluded, network and broadcast addresses
+do not count towards the size.
+
+` + output.FormatHelp(output.DataFormats, map[output.Format]string{
+		output.FormatWide: "table with the exclusions",
+	}) + `
+Example:
+  dnsctl pool usage
+  dnsctl pool usage lab prod -o wide
+  dnsctl pool usage -o jsonpath='{.[*].free}'`,
+	RunE: runPoolUsage,
+}
+
+func init() {
+	rootCmd.AddCommand(poolCmd)
+	poolCmd.AddCommand(poolUsageCmd)
+
+	poolUsageCmd.Flags().StringVarP(&poolOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+}
+
+func runPoolUsage(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(poolOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		if len(cfg.Pools) == 0 {
+			return errdefs.Errorf(errdefs.Config, "no pools configured, define them under pools: in the config")
+		}
+		names = cfg.PoolNames()
+	}
+	pools := make([]*pool.Pool, len(names))
+	for i, name := range names {
+		if pools[i], err = configPool(cfg, name); err != nil {
+			return err
+		}
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+	recs := hosts.Records()
+
+	usage := make([]pool.Usage, len(pools))
+	for i, p := range pools {
+		usage[i] = p.Usage(recs)
+	}
+	return output.Print(pool.Usages(usage), format)
+}
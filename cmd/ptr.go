@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/ptr"
+)
+
+// ptrFormatZone prints a BIND reverse zone file.
+const ptrFormatZone output.Format = "zone"
+
+// ptrFormats are the output formats of ptr.
+var ptrFormats = slices.Insert(slices.Clone(output.DataFormats), 2, ptrFormatZone)
+
+var (
+	ptrPolicy   string
+	ptrZone     string
+	ptrNS       []string
+	ptrMail     string
+	ptrZoneTTL  uint32
+	ptrWrite    bool
+	ptrWriteKey string
+	ptrOutput   string
+)
+
+// ptrCmd represents the ptr command.
+var ptrCmd = &cobra.Command{
+	Use:   "ptr",
+	Short: "Generate reverse (PTR) entries for the stored addresses",
+	Long: `Derive the in-addr.arpa and ip6.arpa names of every stored address and
+the hostname each one points back to.
+
+An address shared by several hostnames gets one canonical name, chosen by
+--policy:
+  shortest - fewest labels, then the shortest name (default)
+  first    - first name in alphabetical order
+  weight   - highest record weight, then as shortest
+  all      - every hostname, one PTR record each
+
+Wildcard hostnames get no reverse entries. --zone limits the entries to
+one reverse zone.
+
+With --write the entries are stored as hosts data, one record per address
+and canonical name, under the ptr_key of the config (default: the hosts
+key with a .ptr suffix) or --write-key. A server reading that key answers
+reverse queries without ambiguity. With --zone only the stored entries in
+that zone are replaced; entries of other zones are kept.
+
+` + output.FormatHelp(ptrFormats, map[output.Format]string{
+		output.FormatWide: "table with the reverse names, other hostnames and TTLs",
+		ptrFormatZone:     "BIND zone file for --zone, or the zone containing all entries",
+	}) + `
+Example:
+  dnsctl ptr
+  dnsctl ptr --policy weight -o wide
+  dnsctl ptr --zone 168.192.in-addr.arpa -o zone --ns ns1.example.com > db.192.168
+  dnsctl ptr --write --write-key /etcdhosts-reverse`,
+	Args: cobra.NoArgs,
+	RunE: runPTR,
+}
+
+func init() {
+	rootCmd.AddCommand(ptrCmd)
+
+	ptrCmd.Flags().StringVar(&ptrPolicy, "policy", ptr.PolicyShortest, "canonical name of shared addresses: "+strings.Join(ptr.Policies, ", "))
+	ptrCmd.Flags().StringVar(&ptrZone, "zone", "", "only addresses in this reverse zone, e.g. 168.192.in-addr.arpa")
+	ptrCmd.Flags().StringSliceVar(&ptrNS, "ns", []string{"localhost."}, "name servers of the zone file, the first is the SOA primary")
+	ptrCmd.Flags().StringVar(&ptrMail, "mail", "", "SOA mailbox of the zone file (default: hostmaster at the first name server)")
+	ptrCmd.Flags().Uint32Var(&ptrZoneTTL, "zone-ttl", 3600, "default TTL of the zone file")
+	ptrCmd.Flags().BoolVar(&ptrWrite, "write", false, "store the entries in etcd")
+	ptrCmd.Flags().StringVar(&ptrWriteKey, "write-key", "", "etcd key for --write, overrides ptr_key")
+	ptrCmd.Flags().StringVarP(&ptrOutput, "output", "o", "table", "output format: "+output.FormatList(ptrFormats))
+}
+
+func runPTR(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(ptrOutput, ptrFormats)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ptr.Policies, ptrPolicy) {
+		return errdefs.Errorf(errdefs.Usage, "invalid --policy %q, must be one of: %s", ptrPolicy, strings.Join(ptr.Policies, ", "))
+	}
+	if ptrZone != "" && !ptr.InZone(ptrZone, "in-addr.arpa.") && !ptr.InZone(ptrZone, "ip6.arpa.") {
+		return errdefs.Errorf(errdefs.Usage, "--zone %s is not a reverse zone under in-addr.arpa or ip6.arpa", ptrZone)
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+	entries, err := ptr.Build(hosts.Records(), ptrPolicy)
+	if err != nil {
+		return err
+	}
+	if ptrZone != "" {
+		entries = ptr.Filter(entries, ptrZone)
+	}
+
+	if ptrWrite {
+		return writePTR(entries, ptrZone)
+	}
+
+	if format == ptrFormatZone {
+		origin := ptrZone
+		if origin == "" {
+			if origin, err = ptr.Origin(entries); err != nil {
+				return errdefs.Errorf(errdefs.Usage, "%v, choose one with --zone", err)
+			}
+		}
+		return ptr.WriteZone(os.Stdout, entries, ptr.ZoneOptions{
+			Origin: origin,
+			NS:     ptrNS,
+			Mail:   ptrMail,
+			Serial: hosts.ModRevision(),
+			TTL:    ptrZoneTTL,
+		})
+	}
+
+	if entries == nil {
+		entries = []ptr.Entry{}
+	}
+	if err := output.Print(ptr.Entries(entries), format); err != nil {
+		return err
+	}
+	if shared := ptr.Shared(entries); len(shared) > 0 && (format == output.FormatTable || format == output.FormatWide) && ptrPolicy != ptr.PolicyAll {
+		fmt.Printf("\n%d address(es) shared by several hostnames, canonical names chosen by policy %s.\n", len(shared), ptrPolicy)
+	}
+	return nil
+}
+
+// writePTR stores entries as hosts data under the PTR key. With a zone,
+// only the stored entries in that zone are replaced.
+func writePTR(entries []ptr.Entry, zone string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	key := ptrWriteKey
+	if key == "" {
+		key = cfg.PTRKey
+	}
+	if key == "" {
+		key = ptr.Key(cfg.Key)
+	}
+	if !strings.HasPrefix(key, "/") {
+		key = "/" + key
+	}
+	if err := config.CheckPTRKey(cfg.Key, key); err != nil {
+		return errdefs.Errorf(errdefs.Usage, "PTR key %s: %v", key, err)
+	}
+
+	cli, err := newClientForKey(key)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	current, err := cli.Read()
+	if err != nil {
+		return err
+	}
+	recs := ptr.Replace(current.Records(), entries, zone)
+	if sameRecords(current.Records(), recs) {
+		fmt.Printf("PTR entries at %s are up to date.\n", key)
+		return nil
+	}
+
+	if err := confirmTarget(fmt.Sprintf("Write %d PTR entries to %s", len(recs), key)); err != nil {
+		return err
+	}
+	replaceRecords(current, recs)
+	if err := cli.Write(current); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d PTR entries to %s.\n", len(recs), key)
+	return nil
+}
+
+// sameRecords reports whether a and b hold the same records with the same
+// attributes, in any order.
+func sameRecords(a, b []client.Record) bool {
+	lines := func(recs []client.Record) []string {
+		out := make([]string, len(recs))
+		for i, r := range recs {
+			out[i] = output.FormatHostsLine(r)
+		}
+		slices.Sort(out)
+		return out
+	}
+	return slices.Equal(lines(a), lines(b))
+}
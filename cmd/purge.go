@@ -15,18 +15,27 @@ var purgeCmd = &cobra.Command{
 This removes all IP mappings for the specified hostname.
 
 Example:
-  dnsctl purge example.com`,
+  dnsctl purge example.com
+  dnsctl purge example.com -m "decommissioned"
+  dnsctl purge example.com --verify-dns 10.0.0.53`,
 	Args: cobra.ExactArgs(1),
 	RunE: runPurge,
 }
 
 func init() {
 	rootCmd.AddCommand(purgeCmd)
+
+	addMessageFlag(purgeCmd)
+	addVerifyFlags(purgeCmd)
 }
 
 func runPurge(cmd *cobra.Command, args []string) error {
 	hostname := args[0]
 
+	if err := confirmTarget("Purge " + hostname); err != nil {
+		return err
+	}
+
 	cli, err := newClient()
 	if err != nil {
 		return err
@@ -38,12 +47,14 @@ func runPurge(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	oldRecs := hosts.Records()
 	hosts.Purge(hostname)
 
 	if err := cli.Write(hosts); err != nil {
 		return err
 	}
+	recordAudit(cli, newAuditEntry("purge"))
 
 	fmt.Printf("Purged: %s\n", hostname)
-	return nil
+	return verifyWrite(oldRecs, hosts.Records())
 }
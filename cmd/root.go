@@ -2,16 +2,43 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	client "github.com/etcdhosts/client-go/v2"
 	"github.com/spf13/cobra"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"golang.org/x/term"
+	"google.golang.org/grpc"
 
 	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/logging"
 )
 
-var cfgFile string
+var (
+	cfgFile    string
+	cfgContext string
+	assumeYes  bool
+	cmdTimeout time.Duration
+	verbose    bool
+	logLevel   string
+	logFormat  string
+	errFormat  string
+
+	// flagOverrides holds config values given as global flags.
+	flagOverrides config.Overrides
+)
 
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
@@ -20,21 +47,148 @@ var rootCmd = &cobra.Command{
 	Long: `dnsctl is a CLI tool for managing DNS records stored in etcd.
 
 It provides commands to edit, list, compare, and manage DNS records
-that are used by the etcdhosts CoreDNS plugin.`,
+that are used by the etcdhosts CoreDNS plugin.
+
+Exit codes:
+  0    success
+  1    other error
+  2    invalid flags or arguments
+  3    invalid or missing config
+  4    etcd unreachable or unavailable
+  5    authentication or permission error
+  6    invalid records or input
+  7    conflict with a concurrent change
+  8    revision, hostname or scheduled change not found
+  9    --timeout expired
+  130  interrupted`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if errFormat != "text" && errFormat != "json" {
+			return errdefs.Errorf(errdefs.Usage, "invalid error format: %s (expected text or json)", errFormat)
+		}
+		if verbose {
+			logLevel = "debug"
+		}
+		var err error
+		if logger, err = logging.New(os.Stderr, logLevel, logFormat); err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+
+		cmdCtx = cmd.Context()
+		if cmdTimeout > 0 {
+			cmdCtx, cancelTimeout = context.WithTimeoutCause(cmdCtx, cmdTimeout,
+				errdefs.Errorf(errdefs.Timeout, "timed out after %s (--timeout)", cmdTimeout))
+			cmd.SetContext(cmdCtx)
+		}
+		return nil
+	},
 }
 
+var (
+	// cmdCtx is canceled on SIGINT or SIGTERM and when --timeout expires.
+	cmdCtx        context.Context
+	cancelTimeout context.CancelFunc = func() {}
+
+	// logger logs etcd requests as selected by --log-level and --log-format.
+	logger = logging.Discard
+)
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore the default behavior so that a second signal exits.
+		<-ctx.Done()
+		stop()
+	}()
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errdefs.Wrap(errdefs.Usage, err)
+	})
+	markArgErrors(rootCmd)
+
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	cancelTimeout()
+	stop()
+	if err != nil {
+		os.Exit(reportError(cmd, err))
 	}
 }
 
+// markArgErrors marks the errors of the argument checks of cmd and its
+// subcommands as usage errors.
+func markArgErrors(cmd *cobra.Command) {
+	if check := cmd.Args; check != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			return errdefs.Wrap(errdefs.Usage, check(cmd, args))
+		}
+	}
+	for _, c := range cmd.Commands() {
+		markArgErrors(c)
+	}
+}
+
+// reportError prints err to stderr as text or, with --error-format json,
+// as a JSON object, and returns the exit code.
+func reportError(cmd *cobra.Command, err error) int {
+	if strings.HasPrefix(err.Error(), "unknown command") {
+		err = errdefs.Wrap(errdefs.Usage, err)
+	}
+	report := errdefs.NewReport(err)
+
+	if errFormat == "json" {
+		_ = json.NewEncoder(os.Stderr).Encode(report)
+		return report.Code
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %s\n", report.Error)
+	for _, d := range report.Details {
+		fmt.Fprintf(os.Stderr, "  - %s\n", d)
+	}
+	if report.Kind == errdefs.Usage {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return report.Code
+}
+
+// commandContext returns the context of the running command.
+func commandContext() context.Context {
+	if cmdCtx == nil {
+		return context.Background()
+	}
+	return cmdCtx
+}
+
 func init() {
 	home, _ := os.UserHomeDir()
 	defaultConfig := filepath.Join(home, ".dnsctl.yaml")
+	if env := os.Getenv("DNSCTL_CONFIG"); env != "" {
+		defaultConfig = env
+	}
 
-	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", defaultConfig, "config file path")
+	flags := rootCmd.PersistentFlags()
+	flags.StringVarP(&cfgFile, "config", "c", defaultConfig, "config file path (env DNSCTL_CONFIG)")
+	flags.StringVar(&cfgContext, "context", os.Getenv("DNSCTL_CONTEXT"), "config context to use (default: current-context)")
+	flags.BoolVarP(&assumeYes, "yes", "y", false, "do not ask for confirmation")
+	flags.DurationVar(&cmdTimeout, "timeout", 0, "abort the command after this duration (0 for no limit)")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "log etcd requests to stderr, with the serving member where known (same as --log-level debug)")
+	flags.StringVar(&logLevel, "log-level", "error", "log level: "+strings.Join(logging.Levels, ", "))
+	flags.StringVar(&logFormat, "log-format", "text", "log format: text, json")
+	flags.StringVar(&errFormat, "error-format", "text", "error format: text, json")
+
+	flags.StringSliceVar(&flagOverrides.Endpoints, "endpoints", nil, "etcd endpoints, overrides the config file")
+	flags.StringVar(&flagOverrides.Key, "key", "", "etcd key for hosts data")
+	flags.DurationVar(&flagOverrides.DialTimeout, "dial-timeout", 0, "etcd dial timeout")
+	flags.DurationVar(&flagOverrides.ReqTimeout, "req-timeout", 0, "etcd request timeout")
+	flags.StringVar(&flagOverrides.CA, "ca", "", "CA certificate file")
+	flags.StringVar(&flagOverrides.Cert, "cert", "", "client certificate file")
+	flags.StringVar(&flagOverrides.CertKey, "cert-key", "", "client key file")
+	flags.StringVar(&flagOverrides.Username, "username", "", "etcd username")
+	flags.StringVar(&flagOverrides.PasswordEnv, "password-env", "", "environment variable containing the etcd password")
+	flags.StringVar(&flagOverrides.PasswordFile, "password-file", "", "file containing the etcd password")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("context", completeContexts)
 }
 
 // SetVersion sets the version string for --version flag.
@@ -42,11 +196,169 @@ func SetVersion(version string) {
 	rootCmd.Version = version
 }
 
+// loadedConfig caches the config of this run so that a password command
+// or prompt runs only once. It is keyed by config file and context.
+var (
+	loadedConfig    *config.Config
+	loadedConfigKey string
+)
+
+// loadConfig loads the selected context from the config file and applies
+// environment and flag overrides. The config file may be omitted when it
+// was not chosen explicitly and the endpoints are given otherwise.
+func loadConfig() (*config.Config, error) {
+	key := cfgFile + "\x00" + cfgContext
+	if loadedConfig != nil && loadedConfigKey == key {
+		cfg := *loadedConfig
+		return &cfg, nil
+	}
+
+	env, err := config.EnvOverrides(os.Getenv)
+	if err != nil {
+		return nil, errdefs.Wrap(errdefs.Config, err)
+	}
+	optional := !rootCmd.PersistentFlags().Changed("config") && os.Getenv("DNSCTL_CONFIG") == ""
+	cfg, err := config.LoadWithOverrides(cfgFile, cfgContext, optional, env.Merge(flagOverrides))
+	if err != nil {
+		return nil, errdefs.Wrap(errdefs.Config, err)
+	}
+	warnConfigPermissions()
+
+	if err := cfg.ResolvePassword(promptPassword); err != nil {
+		return nil, errdefs.Wrap(errdefs.Config, err)
+	}
+
+	logger.Debug("config loaded",
+		"file", cfgFile,
+		"context", cfg.Name,
+		"endpoints", logging.RedactURLs(cfg.Endpoints),
+		"key", cfg.Key,
+		"username", cfg.Username,
+		"password", redacted(cfg.Password),
+	)
+
+	loadedConfig, loadedConfigKey = cfg, key
+	c := *cfg
+	return &c, nil
+}
+
+// redacted hides a secret in logs.
+func redacted(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "REDACTED"
+}
+
+// promptPassword asks for the etcd password on the terminal without echo.
+// It uses /dev/tty so that it works while stdin is redirected.
+func promptPassword(username string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no password for user %s and no terminal to ask for it, set password_file, password_cmd or password_env", username)
+	}
+	defer func() { _ = tty.Close() }()
+
+	fmt.Fprintf(tty, "Password for %s: ", username)
+	password, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(password), nil
+}
+
+var permissionsChecked sync.Once
+
+// warnConfigPermissions warns once if the config file stores a password
+// and is readable by other users.
+func warnConfigPermissions() {
+	permissionsChecked.Do(func() {
+		f, err := config.ReadFile(cfgFile)
+		if err != nil {
+			return
+		}
+		if p := config.CheckPermissions(cfgFile, f); p != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", p.Message)
+		}
+	})
+}
+
 // newClient creates a new etcdhosts client from config.
-func newClient() (*client.Client, error) {
-	cfg, err := config.Load(cfgFile)
+func newClient() (*hostsClient, error) {
+	return newClientForKey("")
+}
+
+// newClientForKey creates an etcdhosts client for key instead of the
+// configured hosts key, e.g. for data derived from the hosts. An empty
+// key selects the configured one.
+func newClientForKey(key string) (*hostsClient, error) {
+	cfg, err := loadConfig()
 	if err != nil {
 		return nil, err
 	}
-	return client.NewClient(cfg.ToClientConfig())
+	if key != "" {
+		cfg.Key = key
+	}
+	cli, err := client.NewClient(cfg.ToClientConfig())
+	if err != nil {
+		return nil, errdefs.Default(errdefs.Config, err)
+	}
+	return &hostsClient{Client: cli, timeout: cfg.ReqTimeout, endpoints: logging.RedactURLs(cfg.Endpoints)}, nil
+}
+
+// newEtcdClient creates a raw etcd client from config.
+// It is used for keys that client-go does not manage.
+func newEtcdClient() (*clientv3.Client, *config.Config, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	etcdCfg, err := cfg.ToEtcdConfig()
+	if err != nil {
+		return nil, nil, errdefs.Wrap(errdefs.Config, err)
+	}
+	etcdCfg.DialOptions = append(etcdCfg.DialOptions, grpc.WithChainUnaryInterceptor(logging.UnaryInterceptor(logger)))
+	etcd, err := clientv3.New(etcdCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+	return etcd, cfg, nil
+}
+
+// confirmTarget shows which cluster a mutating command targets when
+// contexts are in use and, for contexts with confirm enabled, asks the
+// user before continuing.
+func confirmTarget(action string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Name != "" {
+		fmt.Fprintf(os.Stderr, "Target: %s\n", cfg.Describe())
+	}
+	if !cfg.Confirm || assumeYes {
+		return nil
+	}
+
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return errdefs.Errorf(errdefs.Usage, "%s requires confirmation for %s, use --yes", action, cfg.Describe())
+	}
+
+	fmt.Fprintf(os.Stderr, "%s on %s? [y/N] ", action, cfg.Describe())
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("aborted")
+}
+
+// completeContexts completes context names from the config file.
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	f, err := config.ReadFile(cfgFile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return f.ContextNames(), cobra.ShellCompDirectiveNoFileComp
 }
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/schedule"
+	"github.com/etcdhosts/dnsctl/v2/internal/timeutil"
+)
+
+var (
+	scheduleFile     string
+	scheduleAt       string
+	scheduleOutput   string
+	scheduleOnce     bool
+	scheduleInterval time.Duration
+)
+
+// scheduleCmd represents the schedule command.
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage DNS changes applied at a future time",
+	Long: `Manage DNS changes that are applied at a future time.
+
+Scheduled changes are stored in etcd next to the hosts key and are
+applied by 'dnsctl schedule run'. A change is only applied if the hosts
+data was not modified after it was scheduled (single-key mode only).
+
+Example:
+  dnsctl schedule apply -f new.hosts --at 2026-11-01T02:00Z
+  dnsctl schedule list
+  dnsctl schedule cancel 20261101T0200-3fa9c1
+  dnsctl schedule run`,
+}
+
+var scheduleApplyCmd = &cobra.Command{
+	Use:   "apply -f FILE --at TIME",
+	Short: "Schedule a hosts file to replace the current records",
+	Long: `Schedule a hosts file to replace the current records at a given time.
+
+The file is validated now and stored together with the current version
+of the hosts data. Use '-f -' to read from stdin.
+
+Time formats:
+  2026-11-01T02:00:00Z   RFC3339
+  2026-11-01T02:00Z      RFC3339 without seconds
+  2026-11-01 02:00       local time
+
+Example:
+  dnsctl schedule apply -f new.hosts --at 2026-11-01T02:00Z -m "DC cutover"`,
+	Args: cobra.NoArgs,
+	RunE: runScheduleApply,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List scheduled changes",
+	Long: `List scheduled changes and their outcome.
+
+` + output.FormatHelp(output.DataFormats, nil) + `
+Example:
+  dnsctl schedule list
+  dnsctl schedule list -o json`,
+	Args: cobra.NoArgs,
+	RunE: runScheduleList,
+}
+
+var scheduleCancelCmd = &cobra.Command{
+	Use:   "cancel ID...",
+	Short: "Cancel pending scheduled changes",
+	Long: `Cancel one or more pending scheduled changes.
+
+A change claimed by a worker that died before recording the outcome stays
+running; it can be canceled once its claim is older than 10 minutes. If
+the worker finishes after all, it reports the lost claim rather than
+overwriting the cancellation.
+
+Example:
+  dnsctl schedule cancel 20261101T0200-3fa9c1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runScheduleCancel,
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Apply scheduled changes when they are due",
+	Long: `Apply scheduled changes when they are due.
+
+Runs as a worker that checks for due changes every --interval until
+interrupted. Multiple workers may run at the same time; each change is
+claimed by exactly one of them. The outcome is recorded with the change
+and shown by 'dnsctl schedule list'.
+
+Example:
+  dnsctl schedule run
+  dnsctl schedule run --interval 10s
+  dnsctl schedule run --once     # apply due changes and exit`,
+	Args: cobra.NoArgs,
+	RunE: runScheduleRun,
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleApplyCmd, scheduleListCmd, scheduleCancelCmd, scheduleRunCmd)
+
+	scheduleApplyCmd.Flags().StringVarP(&scheduleFile, "file", "f", "", "hosts file to apply ('-' for stdin)")
+	scheduleApplyCmd.Flags().StringVar(&scheduleAt, "at", "", "time to apply the change")
+	_ = scheduleApplyCmd.MarkFlagRequired("file")
+	_ = scheduleApplyCmd.MarkFlagRequired("at")
+	addMessageFlag(scheduleApplyCmd)
+
+	scheduleListCmd.Flags().StringVarP(&scheduleOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+
+	scheduleRunCmd.Flags().BoolVar(&scheduleOnce, "once", false, "apply due changes once and exit")
+	scheduleRunCmd.Flags().DurationVar(&scheduleInterval, "interval", 30*time.Second, "interval between checks")
+}
+
+// newScheduleStore creates a schedule store for the configured hosts key.
+func newScheduleStore() (*schedule.Store, func(), error) {
+	etcd, cfg, err := newEtcdClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	store := schedule.NewStore(etcd, schedule.KeyPrefix(cfg.Key), cfg.ReqTimeout)
+	return store, func() { _ = etcd.Close() }, nil
+}
+
+func runScheduleApply(cmd *cobra.Command, args []string) error {
+	at, err := timeutil.ParseTime(scheduleAt)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Usage, err)
+	}
+	if !at.After(time.Now()) {
+		return errdefs.Errorf(errdefs.Validation, "scheduled time %s is in the past", at.Format(time.RFC3339))
+	}
+
+	data, err := readInput(scheduleFile)
+	if err != nil {
+		return err
+	}
+
+	parseResult := client.ParseRecordsStrict(data)
+	if parseResult.HasErrors() {
+		return invalidRecordsError(parseResult.Errors, " in "+scheduleFile)
+	}
+
+	newHosts, warnings := dedupeRecords(parseResult.Records)
+	if len(warnings) > 0 {
+		fmt.Printf("Warning: removed %d duplicate record(s):\n", len(warnings))
+		for _, warn := range warnings {
+			fmt.Printf("  - %s\n", warn)
+		}
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	mode, err := cli.Mode()
+	if err != nil {
+		return err
+	}
+	if mode == client.ModePerHost {
+		return errdefs.New(errdefs.Usage, "schedule is not supported in perhost mode")
+	}
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+
+	if err := confirmTarget(fmt.Sprintf("Schedule %d records at %s", newHosts.Len(), at.Format(time.RFC3339))); err != nil {
+		return err
+	}
+
+	store, closeStore, err := newScheduleStore()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	change := &schedule.Change{
+		At:          at.UTC(),
+		BaseVersion: hosts.Version(),
+		Records:     newHosts.Len(),
+		Content:     newHosts.String(),
+		Audit:       newAuditEntry("schedule"),
+	}
+	if err := store.Add(commandContext(), change); err != nil {
+		return err
+	}
+
+	fmt.Printf("Scheduled %s at %s (%d records, base version %d).\n",
+		change.ID, at.Local().Format("2006-01-02 15:04:05 MST"), change.Records, change.BaseVersion)
+	return nil
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(scheduleOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+
+	store, closeStore, err := newScheduleStore()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	changes, err := store.List(commandContext())
+	if err != nil {
+		return err
+	}
+
+	if format != output.FormatTable {
+		return output.Print(schedule.Changes(changes), format)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No scheduled changes.")
+		return nil
+	}
+	printScheduleTable(changes)
+	return nil
+}
+
+func runScheduleCancel(cmd *cobra.Command, args []string) error {
+	if err := confirmTarget("Cancel " + strings.Join(args, ", ")); err != nil {
+		return err
+	}
+
+	store, closeStore, err := newScheduleStore()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	for _, id := range args {
+		if err := store.Cancel(commandContext(), id); err != nil {
+			return err
+		}
+		fmt.Printf("Canceled: %s\n", id)
+	}
+	return nil
+}
+
+func runScheduleRun(cmd *cobra.Command, args []string) error {
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	store, closeStore, err := newScheduleStore()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if scheduleOnce {
+		return applyDueChanges(cli, store)
+	}
+
+	ctx := commandContext()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Checking %s for scheduled changes every %s.\n", cfg.Describe(), scheduleInterval)
+	for {
+		if err := applyDueChanges(cli, store); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(scheduleInterval):
+		}
+	}
+}
+
+// applyDueChanges claims and applies every due change, recording each outcome.
+func applyDueChanges(cli *hostsClient, store *schedule.Store) error {
+	ctx := commandContext()
+	changes, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range schedule.Due(changes, time.Now()) {
+		if err := store.Claim(ctx, c); err != nil {
+			if errors.Is(err, schedule.ErrNotPending) {
+				continue
+			}
+			return err
+		}
+
+		revision, applyErr := applyChange(cli, c)
+		// The outcome is recorded even if the command was interrupted
+		// while applying the change.
+		// A lost claim means the change was canceled as stale while it
+		// was applied; the outcome below is all that is left of it.
+		if err := store.Finish(context.WithoutCancel(ctx), c, revision, applyErr); err != nil {
+			if !errors.Is(err, schedule.ErrClaimLost) {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v, the outcome was not recorded\n", err)
+		}
+
+		if errors.Is(applyErr, errdefs.ErrOutcomeUnknown) {
+			fmt.Printf("Unknown: %s: %v, check the hosts data\n", c.ID, applyErr)
+			continue
+		}
+		if applyErr != nil {
+			fmt.Printf("Failed: %s: %v\n", c.ID, applyErr)
+			continue
+		}
+		fmt.Printf("Applied: %s (%d records, revision %d)\n", c.ID, c.Records, revision)
+
+		entry := c.Audit
+		entry.Revision = revision
+		entry.Time = c.Finished
+		if entry.Time.IsZero() {
+			entry.Time = time.Now().UTC()
+		}
+		recordAudit(cli, entry)
+	}
+	return nil
+}
+
+// applyChange writes a scheduled change if the hosts data is still at the
+// version it was scheduled against. Returns the new mod revision.
+func applyChange(cli *hostsClient, c *schedule.Change) (int64, error) {
+	records, err := client.ParseRecords([]byte(c.Content))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse scheduled records: %w", err)
+	}
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return 0, err
+	}
+	if hosts.Version() != c.BaseVersion {
+		return 0, errdefs.Errorf(errdefs.Conflict, "version conflict: current=%d, scheduled against=%d", hosts.Version(), c.BaseVersion)
+	}
+
+	replaceRecords(hosts, records)
+	if err := cli.Write(hosts); err != nil {
+		return 0, err
+	}
+	return cli.revision, nil
+}
+
+// readInput reads a file, or stdin when path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// printScheduleTable prints a table of scheduled changes.
+func printScheduleTable(changes []*schedule.Change) {
+	fmt.Printf("%-20s  %-20s  %-8s  %-8s  %-7s  %s\n", "ID", "AT", "STATUS", "BASE", "RECORDS", "RESULT")
+	fmt.Println("--------------------  --------------------  --------  --------  -------  --------------------")
+
+	for _, c := range changes {
+		fmt.Printf("%-20s  %-20s  %-8s  %-8d  %-7d  %s\n",
+			c.ID,
+			c.At.Local().Format("2006-01-02 15:04:05"),
+			c.Status,
+			c.BaseVersion,
+			c.Records,
+			c.Outcome(),
+		)
+	}
+
+	fmt.Printf("\nTotal: %d scheduled changes\n", len(changes))
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+	"github.com/etcdhosts/dnsctl/v2/internal/simulate"
+)
+
+var (
+	simulateOutput   string
+	simulateQueries  int
+	simulateDown     []string
+	simulateSeed     uint64
+	simulateRevision int64
+	simulateFile     string
+)
+
+// simulateCmd represents the simulate command.
+var simulateCmd = &cobra.Command{
+	Use:   "simulate HOSTNAME",
+	Short: "Simulate the weighted distribution of answers for a hostname",
+	Long: `Simulate how the etcdhosts plugin spreads queries for a hostname over
+its records, and compare the result with the share expected from the
+weights.
+
+Each A and AAAA query is answered with one record of the family, picked
+at random in proportion to its weight among the records whose health
+check passes. --down marks IPs as failing their health check. If every
+record of a family is down, all of them are used.
+
+Use -f to try a hosts file, such as an edited copy, before applying it.
+
+` + output.FormatHelp(output.DataFormats, map[output.Format]string{
+		output.FormatWide: "table with the difference in percentage points",
+	}) + `
+Example:
+  dnsctl simulate api.example.com
+  dnsctl simulate api.example.com -n 100000 --down 10.0.0.2
+  dnsctl simulate api.example.com -f new.hosts
+  dnsctl simulate api.example.com --seed 42 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSimulate,
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().StringVarP(&simulateOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+	simulateCmd.Flags().IntVarP(&simulateQueries, "queries", "n", 10000, "number of queries per address family")
+	simulateCmd.Flags().StringSliceVar(&simulateDown, "down", nil, "IP or CIDR whose health check fails (repeatable)")
+	simulateCmd.Flags().Uint64Var(&simulateSeed, "seed", 0, "random seed for a repeatable simulation (0 for a random one)")
+	simulateCmd.Flags().Int64VarP(&simulateRevision, "revision", "r", 0, "read from specific etcd revision")
+	simulateCmd.Flags().StringVarP(&simulateFile, "file", "f", "", "read records from a hosts file ('-' for stdin) instead of etcd")
+	simulateCmd.MarkFlagsMutuallyExclusive("file", "revision")
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(simulateOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+	if simulateQueries < 1 {
+		return errdefs.Errorf(errdefs.Usage, "invalid number of queries: %d", simulateQueries)
+	}
+	opts := simulate.Options{Queries: simulateQueries, Seed: simulateSeed}
+	for _, s := range simulateDown {
+		n, err := records.ParseNetwork(s)
+		if err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+		opts.Down = append(opts.Down, n)
+	}
+
+	hosts, err := readSimulatedHosts()
+	if err != nil {
+		return err
+	}
+
+	res := records.Resolve(hosts.Records(), args[0], false)
+	if res.Match == "" {
+		return errdefs.Errorf(errdefs.NotFound, "no records for %s", res.Hostname)
+	}
+	recs := make([]client.Record, len(res.Answers))
+	for i, a := range res.Answers {
+		recs[i] = a.Record
+	}
+	for i, n := range opts.Down {
+		if !slices.ContainsFunc(recs, func(r client.Record) bool { return n.Contains(records.Addr(r.IP)) }) {
+			fmt.Fprintf(os.Stderr, "Warning: --down %s matches no record of %s\n", simulateDown[i], res.Hostname)
+		}
+	}
+
+	result := simulate.Run(res.Hostname, recs, opts)
+	if format != output.FormatTable {
+		return output.Print(result, format)
+	}
+
+	fmt.Printf("Simulated %d queries per address family for %s\n", result.Queries, result.Hostname)
+	for _, family := range result.FailOpen {
+		fmt.Printf("Warning: all %s records are down, answering with all of them\n", family)
+	}
+	fmt.Println()
+	return output.Print(result, format)
+}
+
+// readSimulatedHosts reads the records to simulate from --file, or from
+// etcd at the current or a given revision.
+func readSimulatedHosts() (*client.Hosts, error) {
+	if simulateFile != "" {
+		data, err := readInput(simulateFile)
+		if err != nil {
+			return nil, err
+		}
+		parseResult := client.ParseRecordsStrict(data)
+		if parseResult.HasErrors() {
+			return nil, invalidRecordsError(parseResult.Errors, " in "+simulateFile)
+		}
+		hosts, _ := dedupeRecords(parseResult.Records)
+		return hosts, nil
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cli.Close() }()
+
+	if simulateRevision > 0 {
+		return cli.ReadRevision(simulateRevision)
+	}
+	return cli.Read()
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/dnsprobe"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+const (
+	// verifyInterval is the time between queries of a hostname.
+	verifyInterval = 500 * time.Millisecond
+	// verifyQueryTimeout limits each DNS query.
+	verifyQueryTimeout = 2 * time.Second
+)
+
+var (
+	verifyServer  string
+	verifyTimeout time.Duration
+	verifyOutput  string
+)
+
+// verifyCmd represents the verify command.
+var verifyCmd = &cobra.Command{
+	Use:   "verify [HOSTNAME|GLOB...] --server SERVER[:PORT]",
+	Short: "Check that a DNS server answers with the stored records",
+	Long: `Query a DNS server, such as CoreDNS with the etcdhosts plugin, for the
+A and AAAA records of hostnames until its answers match the records
+stored in etcd or --verify-timeout expires.
+
+Answers match once every stored address of a hostname has been answered
+and no other address was. Answers are collected across queries, so a
+server answering with one of several weighted records per query matches
+once it returned each of them.
+
+Without arguments all hostnames are verified. The exit code is 1 if any
+hostname does not match in time.
+
+` + output.FormatHelp(output.DataFormats, map[output.Format]string{
+		output.FormatWide: "table with the expected and the last answered addresses",
+	}) + `
+Example:
+  dnsctl verify --server 10.0.0.53
+  dnsctl verify api.example.com --server 127.0.0.1:1053 --verify-timeout 1m
+  dnsctl edit --verify-dns 10.0.0.53:53`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifyServer, "server", "", "DNS server to query, as HOST[:PORT]")
+	verifyCmd.Flags().DurationVar(&verifyTimeout, "verify-timeout", 30*time.Second, "how long to wait for the answers to match")
+	verifyCmd.Flags().StringVarP(&verifyOutput, "output", "o", "table", "output format: "+output.FormatList(output.DataFormats))
+	_ = verifyCmd.MarkFlagRequired("server")
+}
+
+// addVerifyFlags adds the DNS verification flags to a mutating command.
+func addVerifyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&verifyServer, "verify-dns", "", "after writing, wait until this DNS server (HOST[:PORT]) answers with the new records")
+	cmd.Flags().DurationVar(&verifyTimeout, "verify-timeout", 30*time.Second, "how long --verify-dns waits for the answers to match")
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(verifyOutput, output.DataFormats)
+	if err != nil {
+		return err
+	}
+	server, err := dnsprobe.Server(verifyServer)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Usage, err)
+	}
+	var filter records.Filter
+	for _, arg := range args {
+		p, err := records.ParsePattern(arg)
+		if err != nil {
+			return errdefs.Wrap(errdefs.Usage, err)
+		}
+		filter.Patterns = append(filter.Patterns, p)
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	hosts, err := cli.Read()
+	if err != nil {
+		return err
+	}
+	recs := filter.Apply(hosts.Records())
+	if len(recs) == 0 && len(args) > 0 {
+		return errdefs.Errorf(errdefs.NotFound, "no records for %s", strings.Join(args, ", "))
+	}
+
+	results := dnsprobe.Verify(commandContext(), server, dnsprobe.Expect(recs, hostnamesOf(recs)), verifyOptions())
+	if err := output.Print(dnsprobe.Results(results), format); err != nil {
+		return err
+	}
+	return verifyError(results)
+}
+
+// verifyWrite waits until the --verify-dns server answers with newRecs
+// for every hostname whose addresses differ from oldRecs. It does
+// nothing without --verify-dns.
+func verifyWrite(oldRecs, newRecs []client.Record) error {
+	if verifyServer == "" {
+		return nil
+	}
+	server, err := dnsprobe.Server(verifyServer)
+	if err != nil {
+		return errdefs.Wrap(errdefs.Usage, err)
+	}
+
+	changed := dnsprobe.Changed(oldRecs, newRecs)
+	if len(changed) == 0 {
+		fmt.Println("No address changes to verify.")
+		return nil
+	}
+	fmt.Printf("Verifying %d hostname(s) against %s...\n", len(changed), server)
+	results := dnsprobe.Verify(commandContext(), server, dnsprobe.Expect(newRecs, changed), verifyOptions())
+	if err := output.Print(dnsprobe.Results(results), output.FormatTable); err != nil {
+		return err
+	}
+	return verifyError(results)
+}
+
+func verifyOptions() dnsprobe.Options {
+	return dnsprobe.Options{
+		Timeout:      verifyTimeout,
+		Interval:     verifyInterval,
+		QueryTimeout: min(verifyQueryTimeout, max(verifyTimeout, time.Second)),
+	}
+}
+
+// verifyError returns an error listing the hostnames that did not match.
+func verifyError(results []dnsprobe.Result) error {
+	failed := dnsprobe.Failed(results)
+	if len(failed) == 0 {
+		return nil
+	}
+	details := make([]string, len(failed))
+	for i, r := range failed {
+		details[i] = fmt.Sprintf("%s: %s", r.Hostname, r.Detail)
+	}
+	return &errdefs.Error{
+		Kind:    errdefs.General,
+		Err:     fmt.Errorf("%d hostname(s) not answered as written", len(failed)),
+		Details: details,
+	}
+}
+
+// hostnamesOf returns the distinct hostnames of recs in order.
+func hostnamesOf(recs []client.Record) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range recs {
+		name := records.NormalizeHostname(r.Hostname)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
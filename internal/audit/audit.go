@@ -0,0 +1,126 @@
+// Package audit records who changed the hosts data and why.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Entry annotates a single write of the hosts data.
+type Entry struct {
+	Revision int64     `json:"revision" yaml:"revision"`
+	User     string    `json:"user" yaml:"user"`
+	Host     string    `json:"host" yaml:"host"`
+	Version  string    `json:"version" yaml:"version"`
+	Command  string    `json:"command" yaml:"command"`
+	Message  string    `json:"message,omitempty" yaml:"message,omitempty"`
+	Time     time.Time `json:"time" yaml:"time"`
+}
+
+// NewEntry returns an entry for the current OS user and host.
+// The revision is filled in once the write is done.
+func NewEntry(command, message, version string) Entry {
+	e := Entry{
+		Version: version,
+		Command: command,
+		Message: message,
+		Time:    time.Now().UTC(),
+	}
+	if u, err := user.Current(); err == nil {
+		e.User = u.Username
+	} else {
+		e.User = os.Getenv("USER")
+	}
+	if h, err := os.Hostname(); err == nil {
+		e.Host = h
+	}
+	return e
+}
+
+// Author returns the entry author as user@host.
+func (e *Entry) Author() string {
+	if e.Host == "" {
+		return e.User
+	}
+	return e.User + "@" + e.Host
+}
+
+// KeyPrefix returns the audit prefix for a hosts key.
+// It is a sibling of the hosts key so that per-host data is never touched.
+func KeyPrefix(hostsKey string) string {
+	return strings.TrimSuffix(hostsKey, "/") + ".audit/"
+}
+
+// Store keeps audit entries in etcd, one key per revision.
+type Store struct {
+	kv      clientv3.KV
+	prefix  string
+	timeout time.Duration
+}
+
+// NewStore creates a Store using prefix for all keys.
+func NewStore(kv clientv3.KV, prefix string, timeout time.Duration) *Store {
+	return &Store{kv: kv, prefix: prefix, timeout: timeout}
+}
+
+// Record stores an entry for its revision.
+// An existing entry for the same revision is never overwritten.
+func (s *Store) Record(e Entry) error {
+	if e.Revision <= 0 {
+		return fmt.Errorf("invalid audit revision: %d", e.Revision)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	key := s.key(e.Revision)
+	resp, err := s.kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("audit entry for revision %d already exists", e.Revision)
+	}
+	return nil
+}
+
+// List returns all audit entries keyed by revision.
+func (s *Store) List() (map[int64]*Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	resp, err := s.kv.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	entries := make(map[int64]*Entry, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var e Entry
+		if err := json.Unmarshal(kv.Value, &e); err != nil {
+			continue
+		}
+		entries[e.Revision] = &e
+	}
+	return entries, nil
+}
+
+// key returns the key for a revision, zero padded so keys sort by revision.
+func (s *Store) key(revision int64) string {
+	return s.prefix + fmt.Sprintf("%020d", revision)
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestNewEntry(t *testing.T) {
+	e := NewEntry("edit", "move api to new rack", "v2.1.0")
+
+	if e.Command != "edit" {
+		t.Errorf("Command = %q, want edit", e.Command)
+	}
+	if e.Message != "move api to new rack" {
+		t.Errorf("Message = %q, want %q", e.Message, "move api to new rack")
+	}
+	if e.Version != "v2.1.0" {
+		t.Errorf("Version = %q, want v2.1.0", e.Version)
+	}
+	if e.User == "" {
+		t.Error("User should be set")
+	}
+	if e.Time.IsZero() {
+		t.Error("Time should be set")
+	}
+}
+
+func TestEntry_Author(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    Entry
+		expected string
+	}{
+		{"user and host", Entry{User: "alice", Host: "ops1"}, "alice@ops1"},
+		{"user only", Entry{User: "alice"}, "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.entry.Author(); result != tt.expected {
+				t.Errorf("Author() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	if result := KeyPrefix("/etcdhosts"); result != "/etcdhosts.audit/" {
+		t.Errorf("KeyPrefix() = %q, want /etcdhosts.audit/", result)
+	}
+}
+
+func TestStore_Key(t *testing.T) {
+	s := NewStore(nil, "/etcdhosts.audit/", 0)
+
+	if result := s.key(12345); result != "/etcdhosts.audit/00000000000000012345" {
+		t.Errorf("key() = %q", result)
+	}
+	if s.key(99) >= s.key(100) {
+		t.Error("keys should sort by revision")
+	}
+}
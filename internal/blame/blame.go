@@ -0,0 +1,87 @@
+// Package blame finds the revisions that introduced the current records.
+package blame
+
+import (
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Line annotates a current record with its origin.
+type Line struct {
+	client.Record `yaml:",inline"`
+
+	// AddedRevision is the oldest revision of the unbroken run of versions,
+	// ending at the current one, that contain this hostname and IP.
+	AddedRevision int64     `json:"added_revision" yaml:"added_revision"`
+	AddedAt       time.Time `json:"added_at,omitzero" yaml:"added_at,omitempty"`
+
+	// ChangedRevision is the revision where the record took its current
+	// form, i.e. the last change of its weight, TTL or health check.
+	ChangedRevision int64     `json:"changed_revision" yaml:"changed_revision"`
+	ChangedAt       time.Time `json:"changed_at,omitzero" yaml:"changed_at,omitempty"`
+
+	// Author is who made the change at ChangedRevision, if recorded.
+	Author  string `json:"author,omitempty" yaml:"author,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// Truncated is set when the record is present in the oldest available
+	// version and that version is not the key's first one (e.g. after
+	// compaction), so it may have been added earlier than AddedRevision.
+	Truncated bool `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+}
+
+// Compute annotates every record of the newest version in history.
+// History must be ordered newest first, as returned by client-go.
+// If hostname is not empty, only records for that hostname are returned.
+func Compute(history []*client.Hosts, hostname string) []Line {
+	if len(history) == 0 {
+		return nil
+	}
+	if hostname != "" {
+		hostname = records.NormalizeHostname(hostname)
+	}
+
+	indexes := make([]map[string]client.Record, len(history))
+	index := func(i int) map[string]client.Record {
+		if indexes[i] == nil {
+			indexes[i] = records.Index(history[i].Records())
+		}
+		return indexes[i]
+	}
+
+	var lines []Line
+	for _, r := range history[0].Records() {
+		if hostname != "" && r.Hostname != hostname {
+			continue
+		}
+
+		key := records.Key(r)
+		added, changed := 0, 0
+		sameForm := true
+		for i := 1; i < len(history); i++ {
+			prev, ok := index(i)[key]
+			if !ok {
+				break
+			}
+			added = i
+			if sameForm && records.SameAttrs(prev, r) {
+				changed = i
+			} else {
+				sameForm = false
+			}
+		}
+
+		lines = append(lines, Line{
+			Record:          r,
+			AddedRevision:   history[added].ModRevision(),
+			AddedAt:         history[added].Modified(),
+			ChangedRevision: history[changed].ModRevision(),
+			ChangedAt:       history[changed].Modified(),
+			Truncated:       added == len(history)-1 && history[added].Version() != 1,
+		})
+	}
+	return lines
+}
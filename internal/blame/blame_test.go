@@ -0,0 +1,98 @@
+package blame
+
+import (
+	"testing"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+// version parses hosts data and stamps it with a modification time,
+// which identifies the version in assertions.
+func version(t *testing.T, at time.Time, data string) *client.Hosts {
+	t.Helper()
+	h, err := client.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	h.SetModified(at)
+	return h
+}
+
+func TestCompute(t *testing.T) {
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+	t4 := t3.Add(time.Hour)
+
+	// newest first
+	history := []*client.Hosts{
+		version(t, t4, "10.0.0.1 web.local\n10.0.0.2 api.local # +etcdhosts weight=5\n10.0.0.3 db.local\n"),
+		version(t, t3, "10.0.0.1 web.local\n10.0.0.2 api.local # +etcdhosts weight=2\n"),
+		version(t, t2, "10.0.0.1 web.local\n10.0.0.2 api.local\n10.0.0.3 db.local\n"),
+		version(t, t1, "10.0.0.1 web.local\n"),
+	}
+
+	lines := Compute(history, "")
+	if len(lines) != 3 {
+		t.Fatalf("Compute() returned %d lines, want 3", len(lines))
+	}
+
+	byHost := make(map[string]Line)
+	for _, l := range lines {
+		byHost[l.Hostname] = l
+	}
+
+	tests := []struct {
+		hostname  string
+		added     time.Time
+		changed   time.Time
+		truncated bool
+	}{
+		// unchanged since the oldest available version
+		{"web.local.", t1, t1, true},
+		// added at t2, weight changed at t3 and again at t4
+		{"api.local.", t2, t4, false},
+		// removed at t3 and re-added at t4
+		{"db.local.", t4, t4, false},
+	}
+
+	for _, tt := range tests {
+		l, ok := byHost[tt.hostname]
+		if !ok {
+			t.Errorf("missing line for %s", tt.hostname)
+			continue
+		}
+		if !l.AddedAt.Equal(tt.added) {
+			t.Errorf("%s AddedAt = %v, want %v", tt.hostname, l.AddedAt, tt.added)
+		}
+		if !l.ChangedAt.Equal(tt.changed) {
+			t.Errorf("%s ChangedAt = %v, want %v", tt.hostname, l.ChangedAt, tt.changed)
+		}
+		if l.Truncated != tt.truncated {
+			t.Errorf("%s Truncated = %v, want %v", tt.hostname, l.Truncated, tt.truncated)
+		}
+	}
+}
+
+func TestCompute_Hostname(t *testing.T) {
+	history := []*client.Hosts{
+		version(t, time.Now(), "10.0.0.1 web.local\n10.0.0.2 web.local\n10.0.0.3 db.local\n"),
+	}
+
+	lines := Compute(history, "WEB.local")
+	if len(lines) != 2 {
+		t.Fatalf("Compute(web.local) returned %d lines, want 2", len(lines))
+	}
+	for _, l := range lines {
+		if l.Hostname != "web.local." {
+			t.Errorf("unexpected hostname %s", l.Hostname)
+		}
+	}
+}
+
+func TestCompute_Empty(t *testing.T) {
+	if lines := Compute(nil, ""); lines != nil {
+		t.Errorf("Compute(nil) = %v, want nil", lines)
+	}
+}
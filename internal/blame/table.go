@@ -0,0 +1,37 @@
+package blame
+
+import (
+	"strconv"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Lines are the blame of a set of records.
+type Lines []Line
+
+// Table implements output.Tabular.
+func (ls Lines) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "HOSTNAME"}, {Name: "IP"}, {Name: "ADDED"}, {Name: "ADDED AT"},
+		{Name: "CHANGED"}, {Name: "CHANGED AT"}, {Name: "AUTHOR"},
+		{Name: "ATTRS", Wide: true}, {Name: "MESSAGE", Wide: true},
+	}}
+	for _, l := range ls {
+		added := strconv.FormatInt(l.AddedRevision, 10)
+		if l.Truncated {
+			added += "*"
+		}
+		t.Rows = append(t.Rows, []string{
+			l.Hostname,
+			l.IP.String(),
+			added,
+			output.FormatTime(l.AddedAt),
+			strconv.FormatInt(l.ChangedRevision, 10),
+			output.FormatTime(l.ChangedAt),
+			output.OrDash(l.Author),
+			output.OrDash(output.FormatRecordAttrs(l.Record)),
+			output.OrDash(l.Message),
+		})
+	}
+	return t
+}
@@ -1,15 +1,32 @@
 package config
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	client "github.com/etcdhosts/client-go/v2"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"gopkg.in/yaml.v3"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/pool"
 )
 
 // Config holds the dnsctl configuration.
 type Config struct {
+	// Name is the context this config was loaded from, empty for a
+	// config file without contexts.
+	Name string `yaml:"-"`
+
 	Endpoints   []string      `yaml:"endpoints"`
 	Key         string        `yaml:"key,omitempty"`
 	DialTimeout time.Duration `yaml:"dial_timeout,omitempty"`
@@ -19,6 +36,38 @@ type Config struct {
 	CertKey     string        `yaml:"cert_key,omitempty"`
 	Username    string        `yaml:"username,omitempty"`
 	Password    string        `yaml:"password,omitempty"`
+
+	// PasswordEnv, PasswordFile and PasswordCmd keep the password out of
+	// the config file. At most one password source may be set.
+	PasswordEnv  string `yaml:"password_env,omitempty"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+	PasswordCmd  string `yaml:"password_cmd,omitempty"`
+
+	// Confirm makes mutating commands ask before writing, e.g. for prod.
+	Confirm bool `yaml:"confirm,omitempty"`
+
+	// PTRKey is the etcd key 'dnsctl ptr --write' stores reverse entries
+	// under, by default the hosts key with a .ptr suffix.
+	PTRKey string `yaml:"ptr_key,omitempty"`
+
+	// Pools are the address ranges 'dnsctl alloc' picks addresses from,
+	// keyed by pool name.
+	Pools map[string]Pool `yaml:"pools,omitempty"`
+}
+
+// Pool is an address pool in the config.
+type Pool struct {
+	CIDR string `yaml:"cidr"`
+	// Exclude are IPs or CIDRs in the pool that are never allocated.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// File is the config file. It holds either a single config at the top
+// level or named contexts, kubectl-style.
+type File struct {
+	Config         `yaml:",inline"`
+	CurrentContext string             `yaml:"current-context,omitempty"`
+	Contexts       map[string]*Config `yaml:"contexts,omitempty"`
 }
 
 // ToClientConfig converts Config to client.Config.
@@ -36,30 +85,391 @@ func (c *Config) ToClientConfig() client.Config {
 	}
 }
 
-// Load loads config from file.
+// ToEtcdConfig converts Config to a raw etcd client config.
+// It is used for data dnsctl keeps next to the hosts key.
+func (c *Config) ToEtcdConfig() (clientv3.Config, error) {
+	etcdCfg := clientv3.Config{
+		Endpoints:   c.Endpoints,
+		DialTimeout: c.DialTimeout,
+		Username:    c.Username,
+		Password:    c.Password,
+	}
+
+	if c.Cert != "" && c.CertKey != "" {
+		tlsCfg, err := c.TLSConfig()
+		if err != nil {
+			return clientv3.Config{}, fmt.Errorf("failed to build TLS config: %w", err)
+		}
+		etcdCfg.TLS = tlsCfg
+	}
+
+	return etcdCfg, nil
+}
+
+// TLSConfig builds a TLS config from the CA, Cert and CertKey fields.
+// Each field may be a file path (with ~ expansion) or base64 encoded PEM data.
+// The client certificate is only added if both Cert and CertKey are set.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+
+	if c.Cert != "" || c.CertKey != "" {
+		certData, err := loadCertData(c.Cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cert: %w", err)
+		}
+
+		keyData, err := loadCertData(c.CertKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key: %w", err)
+		}
+
+		tlsCert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key pair: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{tlsCert}
+	}
+
+	if c.CA != "" {
+		caData, err := loadCertData(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(caData)
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
+
+// loadCertData reads certificate data the same way client-go does.
+func loadCertData(path string) ([]byte, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return os.ReadFile(path)
+	}
+
+	return base64.StdEncoding.DecodeString(path)
+}
+
+// Load loads config from file, using the current context if the file
+// defines contexts.
 func Load(path string) (*Config, error) {
+	return LoadContext(path, "")
+}
+
+// LoadContext loads the named context from file.
+// An empty name selects the file's current context.
+func LoadContext(path, name string) (*Config, error) {
+	f, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := f.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ReadFile reads and parses a config file.
+func ReadFile(path string) (*File, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	f, err := decodeFile(data, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// decodeFile parses config file data. In strict mode, unknown fields
+// such as a misspelled option are an error.
+func decodeFile(data []byte, strict bool) (*File, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(strict)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Resolve returns the config of the named context with defaults applied.
+// An empty name selects the current context, or the top-level config if
+// the file defines no contexts.
+func (f *File) Resolve(name string) (*Config, error) {
+	if name == "" {
+		name = f.CurrentContext
+	}
+
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	switch {
+	case name == "" && len(f.Contexts) == 0:
+		cfg = f.Config
+	case name == "":
+		return nil, fmt.Errorf("no current context set, use 'dnsctl config use-context' or --context")
+	default:
+		ctx, ok := f.Contexts[name]
+		if !ok || ctx == nil {
+			return nil, fmt.Errorf("context %q not found", name)
+		}
+		cfg = *ctx
+		cfg.Name = name
+	}
+
+	applyDefaults(&cfg)
+	return &cfg, nil
+}
+
+// ContextNames returns the names of all contexts, sorted.
+func (f *File) ContextNames() []string {
+	names := make([]string, 0, len(f.Contexts))
+	for name := range f.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PoolNames returns the names of all pools, sorted.
+func (c *Config) PoolNames() []string {
+	names := make([]string, 0, len(c.Pools))
+	for name := range c.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pool returns the pool called name, or an error if it is not configured
+// or invalid.
+func (c *Config) Pool(name string) (*pool.Pool, error) {
+	p, ok := c.Pools[name]
+	if !ok {
+		return nil, fmt.Errorf("pool %q not found", name)
+	}
+	return pool.New(name, p.CIDR, p.Exclude)
+}
+
+// SetCurrentContext sets current-context in the config file, keeping the
+// rest of the file (including comments) intact.
+func SetCurrentContext(path, name string) error {
+	f, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found in %s", name, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file: %s", path)
+	}
+	setMappingValue(doc.Content[0], "current-context", name)
+
+	out, err := marshalYAML(&doc)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
+
+// DefaultContext is the name given to the top-level settings of a config
+// file when a context is added to it.
+const DefaultContext = "default"
+
+// Create writes a new config file with mode 0600. It fails if the file
+// already exists.
+func Create(path string, f *File) error {
+	data, err := marshalYAML(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// AddContext adds a named context to an existing config file, keeping its
+// comments. If the file has top-level settings and no contexts, they are
+// moved to a context named DefaultContext which becomes the current
+// context, so that the file still selects the same cluster. Otherwise the
+// current context is only set if the file has none.
+func AddContext(path, name string, cfg *Config) error {
+	f, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Contexts[name]; ok {
+		return fmt.Errorf("context %q already exists in %s", name, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file: %s", path)
+	}
+
+	var ctx yaml.Node
+	if err := ctx.Encode(cfg); err != nil {
+		return err
+	}
+
+	contexts := mappingValue(root, "contexts")
+	if contexts == nil || contexts.Kind != yaml.MappingNode {
+		legacy := &yaml.Node{Kind: yaml.MappingNode}
+		var rest []*yaml.Node
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			switch root.Content[i].Value {
+			case "current-context", "contexts":
+				rest = append(rest, root.Content[i], root.Content[i+1])
+			default:
+				legacy.Content = append(legacy.Content, root.Content[i], root.Content[i+1])
+			}
+		}
+		root.Content = rest
+
+		if contexts == nil {
+			contexts = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, scalarNode("contexts"), contexts)
+		} else {
+			*contexts = yaml.Node{Kind: yaml.MappingNode}
+		}
+		if len(legacy.Content) > 0 {
+			if name == DefaultContext {
+				return fmt.Errorf("context name %q is used for the existing settings in %s", name, path)
+			}
+			contexts.Content = append(contexts.Content, scalarNode(DefaultContext), legacy)
+			setMappingValue(root, "current-context", DefaultContext)
+		}
+	}
+	contexts.Content = append(contexts.Content, scalarNode(name), &ctx)
+
+	if current := mappingValue(root, "current-context"); current == nil || current.Value == "" {
+		setMappingValue(root, "current-context", name)
+	}
+
+	out, err := marshalYAML(&doc)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
+
+// marshalYAML encodes v with the two-space indentation used in the docs.
+func marshalYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
 		return nil, err
 	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingValue returns the value node for key in a YAML mapping node.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
 
-	// Apply defaults
+func scalarNode(value string) *yaml.Node {
+	n := &yaml.Node{}
+	n.SetString(value)
+	return n
+}
+
+// setMappingValue sets a scalar value in a YAML mapping node, adding the
+// key at the top if it does not exist.
+func setMappingValue(m *yaml.Node, key, value string) {
+	if v := mappingValue(m, key); v != nil {
+		v.SetString(value)
+		return
+	}
+	m.Content = append([]*yaml.Node{scalarNode(key), scalarNode(value)}, m.Content...)
+}
+
+// Describe returns a short description of the target cluster for prompts
+// and warnings.
+func (c *Config) Describe() string {
+	target := fmt.Sprintf("%s (key %s)", strings.Join(c.Endpoints, ","), c.Key)
+	if c.Name == "" {
+		return target
+	}
+	return fmt.Sprintf("context %q: %s", c.Name, target)
+}
+
+// applyDefaults fills in unset fields.
+func applyDefaults(cfg *Config) {
 	if cfg.Key == "" {
 		cfg.Key = "/etcdhosts"
 	}
+	if !strings.HasPrefix(cfg.Key, "/") {
+		cfg.Key = "/" + cfg.Key
+	}
+	if cfg.PTRKey != "" && !strings.HasPrefix(cfg.PTRKey, "/") {
+		cfg.PTRKey = "/" + cfg.PTRKey
+	}
 	if cfg.ReqTimeout == 0 {
 		cfg.ReqTimeout = 5 * time.Second
 	}
 	if cfg.DialTimeout == 0 {
 		cfg.DialTimeout = 5 * time.Second
 	}
-
-	return &cfg, nil
 }
 
 // Example returns an example config YAML string.
@@ -45,15 +45,16 @@ func TestLoad_Defaults(t *testing.T) {
 func TestLoad_Full(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "test.yaml")
+	certFile, keyFile := writeTestCert(t, tmpDir)
 	content := `endpoints:
   - https://10.0.0.1:2379
   - https://10.0.0.2:2379
 key: /custom/key
 dial_timeout: 10s
 req_timeout: 15s
-ca: /path/to/ca.pem
-cert: /path/to/cert.pem
-cert_key: /path/to/key.pem
+ca: ` + certFile + `
+cert: ` + certFile + `
+cert_key: ` + keyFile + `
 username: admin
 password: secret
 `
@@ -128,3 +129,284 @@ func TestToClientConfig(t *testing.T) {
 		t.Errorf("Username = %s, want user", clientCfg.Username)
 	}
 }
+
+func TestLoad_KeyLeadingSlash(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "test.yaml")
+	if err := os.WriteFile(configPath, []byte("endpoints:\n  - http://localhost:2379\nkey: dns\n"), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Key != "/dns" {
+		t.Errorf("Key = %s, want /dns", cfg.Key)
+	}
+}
+
+func TestToEtcdConfig(t *testing.T) {
+	cfg := &Config{
+		Endpoints:   []string{"http://localhost:2379"},
+		DialTimeout: 3 * time.Second,
+		Username:    "user",
+		Password:    "pass",
+	}
+
+	etcdCfg, err := cfg.ToEtcdConfig()
+	if err != nil {
+		t.Fatalf("ToEtcdConfig() error = %v", err)
+	}
+	if len(etcdCfg.Endpoints) != 1 || etcdCfg.Endpoints[0] != "http://localhost:2379" {
+		t.Errorf("Endpoints = %v, want [http://localhost:2379]", etcdCfg.Endpoints)
+	}
+	if etcdCfg.DialTimeout != 3*time.Second {
+		t.Errorf("DialTimeout = %v, want 3s", etcdCfg.DialTimeout)
+	}
+	if etcdCfg.Username != "user" || etcdCfg.Password != "pass" {
+		t.Errorf("Username/Password = %s/%s, want user/pass", etcdCfg.Username, etcdCfg.Password)
+	}
+	if etcdCfg.TLS != nil {
+		t.Error("TLS should be nil without cert")
+	}
+}
+
+func TestToEtcdConfig_InvalidCert(t *testing.T) {
+	cfg := &Config{
+		Endpoints: []string{"https://localhost:2379"},
+		Cert:      "/nonexistent/cert.pem",
+		CertKey:   "/nonexistent/key.pem",
+	}
+
+	if _, err := cfg.ToEtcdConfig(); err == nil {
+		t.Error("ToEtcdConfig() with missing cert should return error")
+	}
+}
+
+const contextsConfig = `# clusters
+current-context: prod
+contexts:
+  prod:
+    endpoints:
+      - https://10.0.0.1:2379
+    key: /etcdhosts
+    confirm: true
+  staging:
+    endpoints:
+      - https://10.1.0.1:2379
+    key: staging
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadContext(t *testing.T) {
+	configPath := writeConfig(t, contextsConfig)
+
+	tests := []struct {
+		name     string
+		context  string
+		endpoint string
+		key      string
+		confirm  bool
+	}{
+		{"current context", "", "https://10.0.0.1:2379", "/etcdhosts", true},
+		{"named context", "staging", "https://10.1.0.1:2379", "/staging", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadContext(configPath, tt.context)
+			if err != nil {
+				t.Fatalf("LoadContext() error = %v", err)
+			}
+			if cfg.Endpoints[0] != tt.endpoint {
+				t.Errorf("Endpoints[0] = %s, want %s", cfg.Endpoints[0], tt.endpoint)
+			}
+			if cfg.Key != tt.key {
+				t.Errorf("Key = %s, want %s", cfg.Key, tt.key)
+			}
+			if cfg.Confirm != tt.confirm {
+				t.Errorf("Confirm = %v, want %v", cfg.Confirm, tt.confirm)
+			}
+			if cfg.ReqTimeout != 5*time.Second {
+				t.Errorf("ReqTimeout = %v, want default 5s", cfg.ReqTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadContext_Errors(t *testing.T) {
+	configPath := writeConfig(t, contextsConfig)
+	if _, err := LoadContext(configPath, "dr"); err == nil {
+		t.Error("LoadContext() with unknown context should return error")
+	}
+
+	noCurrent := writeConfig(t, "contexts:\n  prod:\n    endpoints:\n      - http://localhost:2379\n")
+	if _, err := Load(noCurrent); err == nil {
+		t.Error("Load() without current-context should return error")
+	}
+
+	legacy := writeConfig(t, "endpoints:\n  - http://localhost:2379\n")
+	if _, err := LoadContext(legacy, "prod"); err == nil {
+		t.Error("LoadContext() on a file without contexts should return error")
+	}
+}
+
+func TestFile_ContextNames(t *testing.T) {
+	f, err := ReadFile(writeConfig(t, contextsConfig))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	names := f.ContextNames()
+	if len(names) != 2 || names[0] != "prod" || names[1] != "staging" {
+		t.Errorf("ContextNames() = %v, want [prod staging]", names)
+	}
+}
+
+func TestSetCurrentContext(t *testing.T) {
+	configPath := writeConfig(t, contextsConfig)
+
+	if err := SetCurrentContext(configPath, "staging"); err != nil {
+		t.Fatalf("SetCurrentContext() error = %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "staging" {
+		t.Errorf("Name = %s, want staging", cfg.Name)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), "# clusters") {
+		t.Error("SetCurrentContext() should keep comments")
+	}
+	info, _ := os.Stat(configPath)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if err := SetCurrentContext(configPath, "dr"); err == nil {
+		t.Error("SetCurrentContext() with unknown context should return error")
+	}
+}
+
+func TestSetCurrentContext_AddsKey(t *testing.T) {
+	configPath := writeConfig(t, "contexts:\n  prod:\n    endpoints:\n      - http://localhost:2379\n")
+
+	if err := SetCurrentContext(configPath, "prod"); err != nil {
+		t.Fatalf("SetCurrentContext() error = %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "prod" {
+		t.Errorf("Name = %s, want prod", cfg.Name)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	cfg := &Config{Endpoints: []string{"https://a:2379", "https://b:2379"}, Key: "/etcdhosts"}
+	if result := cfg.Describe(); result != "https://a:2379,https://b:2379 (key /etcdhosts)" {
+		t.Errorf("Describe() = %q", result)
+	}
+
+	cfg.Name = "prod"
+	if result := cfg.Describe(); result != `context "prod": https://a:2379,https://b:2379 (key /etcdhosts)` {
+		t.Errorf("Describe() = %q", result)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "dnsctl.yaml")
+	f := &File{Config: Config{Endpoints: []string{"http://localhost:2379"}, Key: "/dns"}}
+	if err := Create(path, f); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("mode = %04o, want 0600", perm)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Key != "/dns" {
+		t.Errorf("Key = %s, want /dns", cfg.Key)
+	}
+
+	if err := Create(path, f); err == nil {
+		t.Error("Create() should not overwrite an existing file")
+	}
+}
+
+func TestAddContext(t *testing.T) {
+	path := writeConfig(t, contextsConfig)
+	cfg := &Config{Endpoints: []string{"http://10.2.0.1:2379"}, Key: "/dr"}
+	if err := AddContext(path, "dr", cfg); err != nil {
+		t.Fatalf("AddContext() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# clusters") {
+		t.Error("AddContext() should keep comments")
+	}
+	f, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if f.CurrentContext != "prod" {
+		t.Errorf("CurrentContext = %s, want prod", f.CurrentContext)
+	}
+	if got, _ := f.Resolve("dr"); got == nil || got.Key != "/dr" {
+		t.Errorf("Resolve(dr) = %+v, want key /dr", got)
+	}
+
+	if err := AddContext(path, "dr", cfg); err == nil {
+		t.Error("AddContext() with an existing name should return error")
+	}
+}
+
+func TestAddContext_Legacy(t *testing.T) {
+	path := writeConfig(t, "endpoints:\n  - http://localhost:2379\nkey: /legacy\n")
+	cfg := &Config{Endpoints: []string{"http://10.2.0.1:2379"}}
+
+	if err := AddContext(path, DefaultContext, cfg); err == nil {
+		t.Error("AddContext() should not reuse the default context name")
+	}
+	if err := AddContext(path, "dr", cfg); err != nil {
+		t.Fatalf("AddContext() error = %v", err)
+	}
+
+	f, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if f.CurrentContext != DefaultContext {
+		t.Errorf("CurrentContext = %s, want %s", f.CurrentContext, DefaultContext)
+	}
+	if len(f.Endpoints) != 0 {
+		t.Errorf("top-level endpoints should be moved, got %v", f.Endpoints)
+	}
+	if got, _ := f.Resolve(""); got == nil || got.Key != "/legacy" {
+		t.Errorf("Resolve() = %+v, want the legacy config", got)
+	}
+	if names := f.ContextNames(); len(names) != 2 {
+		t.Errorf("ContextNames() = %v, want [default dr]", names)
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Overrides holds config values from environment variables or flags.
+// Zero values are not applied.
+type Overrides struct {
+	Endpoints    []string
+	Key          string
+	DialTimeout  time.Duration
+	ReqTimeout   time.Duration
+	CA           string
+	Cert         string
+	CertKey      string
+	Username     string
+	Password     string
+	PasswordEnv  string
+	PasswordFile string
+	PasswordCmd  string
+	PTRKey       string
+	Confirm      *bool
+}
+
+// envVar maps a DNSCTL_* variable to its etcdctl-compatible fallback.
+type envVar struct {
+	name    string
+	etcdctl string
+}
+
+var (
+	envEndpoints    = envVar{"DNSCTL_ENDPOINTS", "ETCDCTL_ENDPOINTS"}
+	envKey          = envVar{"DNSCTL_KEY", ""}
+	envDialTimeout  = envVar{"DNSCTL_DIAL_TIMEOUT", "ETCDCTL_DIAL_TIMEOUT"}
+	envReqTimeout   = envVar{"DNSCTL_REQ_TIMEOUT", "ETCDCTL_COMMAND_TIMEOUT"}
+	envCA           = envVar{"DNSCTL_CA", "ETCDCTL_CACERT"}
+	envCert         = envVar{"DNSCTL_CERT", "ETCDCTL_CERT"}
+	envCertKey      = envVar{"DNSCTL_CERT_KEY", "ETCDCTL_KEY"}
+	envUsername     = envVar{"DNSCTL_USERNAME", "ETCDCTL_USER"}
+	envPassword     = envVar{"DNSCTL_PASSWORD", "ETCDCTL_PASSWORD"}
+	envPasswordEnv  = envVar{"DNSCTL_PASSWORD_ENV", ""}
+	envPasswordFile = envVar{"DNSCTL_PASSWORD_FILE", ""}
+	envPasswordCmd  = envVar{"DNSCTL_PASSWORD_CMD", ""}
+	envPTRKey       = envVar{"DNSCTL_PTR_KEY", ""}
+	envConfirm      = envVar{"DNSCTL_CONFIRM", ""}
+)
+
+// lookup returns the DNSCTL_* value, falling back to ETCDCTL_*.
+func (v envVar) lookup(getenv func(string) string) (string, string) {
+	if s := getenv(v.name); s != "" {
+		return s, v.name
+	}
+	if v.etcdctl != "" {
+		if s := getenv(v.etcdctl); s != "" {
+			return s, v.etcdctl
+		}
+	}
+	return "", ""
+}
+
+// EnvOverrides reads overrides from DNSCTL_* environment variables, with
+// the etcdctl variables (ETCDCTL_ENDPOINTS, ETCDCTL_CACERT, ...) as
+// fallback. ETCDCTL_USER may be given as user:password like etcdctl.
+func EnvOverrides(getenv func(string) string) (Overrides, error) {
+	var o Overrides
+	var errs []error
+
+	if s, _ := envEndpoints.lookup(getenv); s != "" {
+		o.Endpoints = splitList(s)
+	}
+	o.Key, _ = envKey.lookup(getenv)
+	o.CA, _ = envCA.lookup(getenv)
+	o.Cert, _ = envCert.lookup(getenv)
+	o.CertKey, _ = envCertKey.lookup(getenv)
+	o.Password, _ = envPassword.lookup(getenv)
+	o.PasswordEnv, _ = envPasswordEnv.lookup(getenv)
+	o.PasswordFile, _ = envPasswordFile.lookup(getenv)
+	o.PasswordCmd, _ = envPasswordCmd.lookup(getenv)
+	o.PTRKey, _ = envPTRKey.lookup(getenv)
+
+	if s, name := envUsername.lookup(getenv); s != "" {
+		o.Username = s
+		if name == envUsername.etcdctl {
+			if user, pass, ok := strings.Cut(s, ":"); ok {
+				o.Username = user
+				if o.Password == "" {
+					o.Password = pass
+				}
+			}
+		}
+	}
+
+	for _, d := range []struct {
+		v   envVar
+		dst *time.Duration
+	}{
+		{envDialTimeout, &o.DialTimeout},
+		{envReqTimeout, &o.ReqTimeout},
+	} {
+		if s, name := d.v.lookup(getenv); s != "" {
+			t, err := time.ParseDuration(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+				continue
+			}
+			*d.dst = t
+		}
+	}
+
+	if s, name := envConfirm.lookup(getenv); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+		} else {
+			o.Confirm = &b
+		}
+	}
+
+	return o, errors.Join(errs...)
+}
+
+// Merge returns o with the values set in p taking precedence.
+func (o Overrides) Merge(p Overrides) Overrides {
+	if len(p.Endpoints) > 0 {
+		o.Endpoints = p.Endpoints
+	}
+	mergeString(&o.Key, p.Key)
+	mergeString(&o.CA, p.CA)
+	mergeString(&o.Cert, p.Cert)
+	mergeString(&o.CertKey, p.CertKey)
+	mergeString(&o.Username, p.Username)
+	mergeString(&o.PTRKey, p.PTRKey)
+	if p.hasPassword() {
+		o.Password, o.PasswordEnv, o.PasswordFile, o.PasswordCmd = p.Password, p.PasswordEnv, p.PasswordFile, p.PasswordCmd
+	}
+	if p.DialTimeout != 0 {
+		o.DialTimeout = p.DialTimeout
+	}
+	if p.ReqTimeout != 0 {
+		o.ReqTimeout = p.ReqTimeout
+	}
+	if p.Confirm != nil {
+		o.Confirm = p.Confirm
+	}
+	return o
+}
+
+// Apply sets the override values on cfg, merging them like Merge.
+func (o Overrides) Apply(c *Config) {
+	m := Overrides{
+		Endpoints:    c.Endpoints,
+		Key:          c.Key,
+		DialTimeout:  c.DialTimeout,
+		ReqTimeout:   c.ReqTimeout,
+		CA:           c.CA,
+		Cert:         c.Cert,
+		CertKey:      c.CertKey,
+		Username:     c.Username,
+		Password:     c.Password,
+		PasswordEnv:  c.PasswordEnv,
+		PasswordFile: c.PasswordFile,
+		PasswordCmd:  c.PasswordCmd,
+		PTRKey:       c.PTRKey,
+		Confirm:      &c.Confirm,
+	}.Merge(o)
+
+	if o.hasPassword() {
+		c.clearPassword()
+	}
+	c.Endpoints, c.Key = m.Endpoints, m.Key
+	c.DialTimeout, c.ReqTimeout = m.DialTimeout, m.ReqTimeout
+	c.CA, c.Cert, c.CertKey = m.CA, m.Cert, m.CertKey
+	c.Username, c.Password = m.Username, m.Password
+	c.PasswordEnv, c.PasswordFile, c.PasswordCmd = m.PasswordEnv, m.PasswordFile, m.PasswordCmd
+	c.PTRKey = m.PTRKey
+	c.Confirm = *m.Confirm
+}
+
+// LoadWithOverrides loads the named context from path and applies
+// overrides on top. Precedence, highest first: overrides, config file,
+// defaults. If optional is set, a missing config file is not an error
+// as long as the overrides supply the endpoints.
+func LoadWithOverrides(path, name string, optional bool, o Overrides) (*Config, error) {
+	f, err := ReadFile(path)
+	if err != nil {
+		if !optional || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if len(o.Endpoints) == 0 {
+			return nil, fmt.Errorf("config file %s not found and no endpoints given (set DNSCTL_ENDPOINTS or --endpoints)", path)
+		}
+		f = &File{}
+	}
+
+	cfg, err := f.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	o.Apply(cfg)
+	applyDefaults(cfg)
+
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints configured (set endpoints in %s, DNSCTL_ENDPOINTS or --endpoints)", path)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// hasPassword reports whether o sets any password source. A password
+// source replaces all sources from lower precedence levels.
+func (o Overrides) hasPassword() bool {
+	return o.Password != "" || o.PasswordEnv != "" || o.PasswordFile != "" || o.PasswordCmd != ""
+}
+
+func mergeString(dst *string, s string) {
+	if s != "" {
+		*dst = s
+	}
+}
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envMap(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestEnvOverrides(t *testing.T) {
+	o, err := EnvOverrides(envMap(map[string]string{
+		"DNSCTL_ENDPOINTS":        "http://a:2379, http://b:2379,",
+		"ETCDCTL_ENDPOINTS":       "http://ignored:2379",
+		"DNSCTL_KEY":              "/custom",
+		"ETCDCTL_CACERT":          "/ca.pem",
+		"ETCDCTL_KEY":             "/client-key.pem",
+		"DNSCTL_DIAL_TIMEOUT":     "2s",
+		"ETCDCTL_COMMAND_TIMEOUT": "7s",
+		"ETCDCTL_USER":            "root:secret",
+		"DNSCTL_CONFIRM":          "true",
+		"DNSCTL_PTR_KEY":          "/reverse",
+		"DNSCTL_PASSWORD_ENV":     "ETCD_PASSWORD",
+	}))
+	if err != nil {
+		t.Fatalf("EnvOverrides() error = %v", err)
+	}
+
+	if want := []string{"http://a:2379", "http://b:2379"}; !reflect.DeepEqual(o.Endpoints, want) {
+		t.Errorf("Endpoints = %v, want %v", o.Endpoints, want)
+	}
+	if o.Key != "/custom" || o.CA != "/ca.pem" || o.CertKey != "/client-key.pem" {
+		t.Errorf("Key/CA/CertKey = %q/%q/%q", o.Key, o.CA, o.CertKey)
+	}
+	if o.DialTimeout != 2*time.Second || o.ReqTimeout != 7*time.Second {
+		t.Errorf("timeouts = %v/%v", o.DialTimeout, o.ReqTimeout)
+	}
+	if o.Username != "root" || o.Password != "secret" {
+		t.Errorf("Username/Password = %q/%q, want root/secret", o.Username, o.Password)
+	}
+	if o.Confirm == nil || !*o.Confirm {
+		t.Errorf("Confirm = %v, want true", o.Confirm)
+	}
+	if o.PasswordEnv != "ETCD_PASSWORD" {
+		t.Errorf("PasswordEnv = %q, want ETCD_PASSWORD", o.PasswordEnv)
+	}
+	if o.PTRKey != "/reverse" {
+		t.Errorf("PTRKey = %q, want /reverse", o.PTRKey)
+	}
+}
+
+func TestEnvOverrides_Invalid(t *testing.T) {
+	_, err := EnvOverrides(envMap(map[string]string{
+		"DNSCTL_DIAL_TIMEOUT": "soon",
+		"DNSCTL_CONFIRM":      "maybe",
+	}))
+	if err == nil {
+		t.Fatal("EnvOverrides() should fail")
+	}
+	for _, name := range []string{"DNSCTL_DIAL_TIMEOUT", "DNSCTL_CONFIRM"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q should mention %s", err, name)
+		}
+	}
+}
+
+func TestOverrides_Merge(t *testing.T) {
+	no := false
+	env := Overrides{Endpoints: []string{"http://env:2379"}, Key: "/env", Username: "env"}
+	flags := Overrides{Endpoints: []string{"http://flag:2379"}, Confirm: &no}
+	env.Password = "from-env"
+
+	got := env.Merge(flags)
+	if got.Endpoints[0] != "http://flag:2379" {
+		t.Errorf("Endpoints = %v, flags should win", got.Endpoints)
+	}
+	if got.Key != "/env" || got.Username != "env" {
+		t.Errorf("unset flags should keep env values, got %+v", got)
+	}
+	if got.Confirm == nil || *got.Confirm {
+		t.Errorf("Confirm = %v, want false", got.Confirm)
+	}
+	if got.Password != "from-env" {
+		t.Errorf("Password = %q, want the env password without a flag source", got.Password)
+	}
+
+	// A higher precedence password_env replaces the lower sources.
+	got = env.Merge(Overrides{PasswordEnv: "ETCD_PASSWORD"})
+	if got.Password != "" || got.PasswordEnv != "ETCD_PASSWORD" {
+		t.Errorf("Merge() = %+v, want only password_env", got)
+	}
+}
+
+func TestLoadWithOverrides(t *testing.T) {
+	path := writeConfig(t, contextsConfig)
+
+	cfg, err := LoadWithOverrides(path, "prod", false, Overrides{
+		Key:        "custom",
+		ReqTimeout: time.Second,
+		PTRKey:     "custom.ptr",
+	})
+	if err != nil {
+		t.Fatalf("LoadWithOverrides() error = %v", err)
+	}
+	if cfg.Name != "prod" {
+		t.Errorf("Name = %q, want prod", cfg.Name)
+	}
+	if cfg.Key != "/custom" {
+		t.Errorf("Key = %q, want /custom", cfg.Key)
+	}
+	if cfg.ReqTimeout != time.Second {
+		t.Errorf("ReqTimeout = %v, want 1s", cfg.ReqTimeout)
+	}
+	if cfg.PTRKey != "/custom.ptr" {
+		t.Errorf("PTRKey = %q, want /custom.ptr", cfg.PTRKey)
+	}
+}
+
+func TestLoadWithOverrides_NoFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	endpoints := Overrides{Endpoints: []string{"http://localhost:2379"}}
+
+	cfg, err := LoadWithOverrides(missing, "", true, endpoints)
+	if err != nil {
+		t.Fatalf("LoadWithOverrides() error = %v", err)
+	}
+	if cfg.Key != "/etcdhosts" || cfg.DialTimeout != 5*time.Second {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+
+	if _, err := LoadWithOverrides(missing, "", true, Overrides{}); err == nil {
+		t.Error("missing file without endpoints should fail")
+	}
+	if _, err := LoadWithOverrides(missing, "", false, endpoints); err == nil {
+		t.Error("missing file should fail when not optional")
+	}
+	if _, err := LoadWithOverrides(missing, "prod", true, endpoints); err == nil {
+		t.Error("unknown context should fail without a config file")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// passwordSources returns the names of the password options that are set.
+func (c *Config) passwordSources() []string {
+	var sources []string
+	for _, s := range []struct{ name, value string }{
+		{"password", c.Password},
+		{"password_env", c.PasswordEnv},
+		{"password_file", c.PasswordFile},
+		{"password_cmd", c.PasswordCmd},
+	} {
+		if s.value != "" {
+			sources = append(sources, s.name)
+		}
+	}
+	return sources
+}
+
+// clearPassword removes every password source.
+func (c *Config) clearPassword() {
+	c.Password, c.PasswordEnv, c.PasswordFile, c.PasswordCmd = "", "", "", ""
+}
+
+// ResolvePassword sets Password from password_env, password_file or
+// password_cmd. If no source is configured but a username is, prompt is
+// called to ask for the password; with a nil prompt that is an error.
+func (c *Config) ResolvePassword(prompt func(username string) (string, error)) error {
+	var err error
+	switch {
+	case c.Password != "":
+		return nil
+	case c.PasswordEnv != "":
+		c.Password = os.Getenv(c.PasswordEnv)
+		if c.Password == "" {
+			return fmt.Errorf("password_env: environment variable %s is not set", c.PasswordEnv)
+		}
+	case c.PasswordFile != "":
+		c.Password, err = readPasswordFile(c.PasswordFile)
+	case c.PasswordCmd != "":
+		c.Password, err = runPasswordCmd(c.PasswordCmd)
+	case c.Username != "":
+		if prompt == nil {
+			return fmt.Errorf("no password for user %s, set password_file, password_cmd or password_env", c.Username)
+		}
+		c.Password, err = prompt(c.Username)
+	}
+	return err
+}
+
+// readPasswordFile reads a password from the first line of a file.
+func readPasswordFile(path string) (string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("password_file: %w", err)
+	}
+	password, _, _ := strings.Cut(string(data), "\n")
+	password = strings.TrimSuffix(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("password_file: %s is empty", path)
+	}
+	return password, nil
+}
+
+// runPasswordCmd runs a shell command and uses the first line of its
+// output as password, e.g. "pass show etcd".
+func runPasswordCmd(command string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("password_cmd %q failed: %w", command, err)
+	}
+
+	password, _, _ := strings.Cut(stdout.String(), "\n")
+	password = strings.TrimSuffix(password, "\r")
+	if password == "" {
+		return "", errors.New("password_cmd: command printed no password")
+	}
+	return password, nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(path, "~", home, 1), nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolvePassword(t *testing.T) {
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "pass")
+	if err := os.WriteFile(passFile, []byte("from-file\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_ETCD_PASSWORD", "from-env")
+
+	prompt := func(user string) (string, error) { return "prompted-" + user, nil }
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"plain", Config{Username: "root", Password: "plain"}, "plain"},
+		{"env", Config{Username: "root", PasswordEnv: "TEST_ETCD_PASSWORD"}, "from-env"},
+		{"file", Config{Username: "root", PasswordFile: passFile}, "from-file"},
+		{"cmd", Config{Username: "root", PasswordCmd: "echo from-cmd"}, "from-cmd"},
+		{"prompt", Config{Username: "root"}, "prompted-root"},
+		{"no auth", Config{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if err := cfg.ResolvePassword(prompt); err != nil {
+				t.Fatalf("ResolvePassword() error = %v", err)
+			}
+			if cfg.Password != tt.want {
+				t.Errorf("Password = %q, want %q", cfg.Password, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePassword_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		prompt  func(string) (string, error)
+		wantErr string
+	}{
+		{"env unset", Config{PasswordEnv: "TEST_ETCD_UNSET"}, nil, "TEST_ETCD_UNSET is not set"},
+		{"file missing", Config{PasswordFile: "/nonexistent/pass"}, nil, "password_file"},
+		{"cmd fails", Config{PasswordCmd: "exit 3"}, nil, "password_cmd"},
+		{"cmd empty", Config{PasswordCmd: "true"}, nil, "printed no password"},
+		{"no prompt", Config{Username: "root"}, nil, "no password for user root"},
+		{"prompt fails", Config{Username: "root"}, func(string) (string, error) { return "", errors.New("no tty") }, "no tty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			err := cfg.ResolvePassword(tt.prompt)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolvePassword() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_PasswordSources(t *testing.T) {
+	cfg := Config{Endpoints: []string{"http://localhost:2379"}, Password: "x", PasswordCmd: "pass show etcd"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "password_cmd: conflicts with password") {
+		t.Errorf("Validate() error = %v, want conflict", err)
+	}
+
+	// Overrides replace every password source of the config file.
+	Overrides{PasswordFile: "/run/secrets/etcd"}.Apply(&cfg)
+	if cfg.Password != "" || cfg.PasswordCmd != "" || cfg.PasswordFile != "/run/secrets/etcd" {
+		t.Errorf("Apply() = %+v, want only password_file", cfg)
+	}
+	Overrides{PasswordEnv: "ETCD_PASSWORD"}.Apply(&cfg)
+	if cfg.PasswordFile != "" || cfg.PasswordEnv != "ETCD_PASSWORD" {
+		t.Errorf("Apply() = %+v, want only password_env", cfg)
+	}
+}
+
+func TestCheck_PasswordFilePermissions(t *testing.T) {
+	passFile := filepath.Join(t.TempDir(), "pass")
+	if err := os.WriteFile(passFile, []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, "endpoints:\n  - http://localhost:2379\nusername: root\npassword_file: "+passFile+"\n")
+
+	problems, err := Check(path)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if len(problems) != 1 || !problems[0].Warning || problems[0].Field != "password_file" {
+		t.Errorf("Check() = %v, want a password_file warning", problems)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load() should ignore warnings, got %v", err)
+	}
+}
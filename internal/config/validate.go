@@ -0,0 +1,269 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/audit"
+	"github.com/etcdhosts/dnsctl/v2/internal/schedule"
+)
+
+// Problem is an issue found in a config file.
+type Problem struct {
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+	Field   string `json:"field,omitempty" yaml:"field,omitempty"`
+	Message string `json:"message" yaml:"message"`
+	Warning bool   `json:"warning,omitempty" yaml:"warning,omitempty"`
+}
+
+// String formats the problem as "context NAME: FIELD: MESSAGE".
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.Context != "" {
+		fmt.Fprintf(&b, "context %s: ", p.Context)
+	}
+	if p.Field != "" {
+		b.WriteString(p.Field + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Validate checks the endpoints, TLS files and password sources of the
+// config. All errors are returned at once; warnings are ignored.
+func (c *Config) Validate() error {
+	var errs []error
+	for _, p := range c.problems() {
+		if !p.Warning {
+			errs = append(errs, errors.New(p.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// problems returns every problem with the config's values.
+func (c *Config) problems() []Problem {
+	var problems []Problem
+	add := func(field, format string, args ...any) {
+		problems = append(problems, Problem{Context: c.Name, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(c.Endpoints) == 0 {
+		add("endpoints", "no endpoints configured")
+	}
+	for _, ep := range c.Endpoints {
+		if err := CheckEndpoint(ep); err != nil {
+			add("endpoints", "%v", err)
+		}
+	}
+
+	if c.DialTimeout < 0 {
+		add("dial_timeout", "must not be negative")
+	}
+	if c.ReqTimeout < 0 {
+		add("req_timeout", "must not be negative")
+	}
+
+	if c.CA != "" {
+		if data, err := readCertData(c.CA); err != nil {
+			add("ca", "%v", err)
+		} else if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			add("ca", "no PEM certificates found")
+		}
+	}
+
+	switch {
+	case c.Cert != "" && c.CertKey == "":
+		add("cert_key", "must be set together with cert")
+	case c.Cert == "" && c.CertKey != "":
+		add("cert", "must be set together with cert_key")
+	case c.Cert != "":
+		cert, certErr := readCertData(c.Cert)
+		if certErr != nil {
+			add("cert", "%v", certErr)
+		}
+		key, keyErr := readCertData(c.CertKey)
+		if keyErr != nil {
+			add("cert_key", "%v", keyErr)
+		}
+		if certErr == nil && keyErr == nil {
+			if _, err := tls.X509KeyPair(cert, key); err != nil {
+				add("cert", "invalid key pair: %v", err)
+			}
+		}
+	}
+
+	if c.PTRKey != "" {
+		if err := CheckPTRKey(c.Key, c.PTRKey); err != nil {
+			add("ptr_key", "%v", err)
+		}
+	}
+
+	for _, name := range c.PoolNames() {
+		if _, err := c.Pool(name); err != nil {
+			add("pools."+name, "%v", err)
+		}
+	}
+
+	if sources := c.passwordSources(); len(sources) > 1 {
+		add(sources[1], "conflicts with %s, set only one password source", sources[0])
+	}
+	if c.PasswordFile != "" {
+		if path, err := expandHome(c.PasswordFile); err != nil {
+			add("password_file", "%v", err)
+		} else if info, err := os.Stat(path); err != nil {
+			add("password_file", "%v", err)
+		} else if perm := info.Mode().Perm(); perm&0o044 != 0 {
+			problems = append(problems, Problem{
+				Context: c.Name,
+				Field:   "password_file",
+				Message: fmt.Sprintf("%s is readable by other users (mode %04o), run 'chmod 600 %s'", path, perm, path),
+				Warning: true,
+			})
+		}
+	}
+
+	return problems
+}
+
+// CheckEndpoint accepts http(s) and unix(s) URLs as well as host:port,
+// like the etcd client.
+func CheckEndpoint(ep string) error {
+	u, err := url.Parse(ep)
+	if err == nil {
+		switch u.Scheme {
+		case "http", "https":
+			if u.Host == "" {
+				return fmt.Errorf("endpoint %q has no host", ep)
+			}
+			return nil
+		case "unix", "unixs":
+			return nil
+		}
+	}
+	if !strings.Contains(ep, "://") {
+		if _, port, err := net.SplitHostPort(ep); err == nil && port != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid endpoint %q, want a URL like https://host:2379", ep)
+}
+
+// readCertData is loadCertData with an error that tells what is wrong.
+func readCertData(value string) ([]byte, error) {
+	data, err := loadCertData(value)
+	if err == nil {
+		return data, nil
+	}
+	if len(value) > 64 {
+		return nil, errors.New("invalid base64 PEM data")
+	}
+	return nil, fmt.Errorf("%s: file not found or not readable", value)
+}
+
+// Check reads the config file at path and reports every problem: unknown
+// fields, invalid endpoints, unusable TLS files, a missing current context
+// and a password readable by other users. Only an unreadable file or
+// invalid YAML is returned as error.
+func Check(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	f, err := decodeFile(data, true)
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		for _, msg := range typeErr.Errors {
+			problems = append(problems, Problem{Message: msg})
+		}
+		f, err = decodeFile(data, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.Contexts) == 0 {
+		problems = append(problems, f.Config.problems()...)
+	} else {
+		if _, ok := f.Contexts[f.CurrentContext]; f.CurrentContext != "" && !ok {
+			problems = append(problems, Problem{
+				Field:   "current-context",
+				Message: fmt.Sprintf("context %q not found", f.CurrentContext),
+			})
+		}
+		for _, name := range f.ContextNames() {
+			var cfg Config
+			if f.Contexts[name] != nil {
+				cfg = *f.Contexts[name]
+			}
+			cfg.Name = name
+			problems = append(problems, cfg.problems()...)
+		}
+	}
+
+	if p := CheckPermissions(path, f); p != nil {
+		problems = append(problems, *p)
+	}
+	return problems, nil
+}
+
+// CheckPermissions returns a warning if the config file stores a password
+// and can be read by other users.
+func CheckPermissions(path string, f *File) *Problem {
+	if !f.hasPassword() {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	perm := info.Mode().Perm()
+	if perm&0o044 == 0 {
+		return nil
+	}
+	return &Problem{
+		Field:   "password",
+		Message: fmt.Sprintf("%s is readable by other users (mode %04o), run 'chmod 600 %s'", path, perm, path),
+		Warning: true,
+	}
+}
+
+// hasPassword reports whether any config in the file stores a password.
+func (f *File) hasPassword() bool {
+	if f.Password != "" {
+		return true
+	}
+	for _, c := range f.Contexts {
+		if c != nil && c.Password != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPTRKey returns an error if ptrKey would overwrite data of the hosts
+// key: the key itself, the per-host keys under it, or its schedule and
+// audit keys.
+func CheckPTRKey(hostsKey, ptrKey string) error {
+	hosts := strings.TrimSuffix(hostsKey, "/")
+	key := strings.TrimSuffix(ptrKey, "/")
+	if key == hosts {
+		return errors.New("must differ from key")
+	}
+	for _, prefix := range []string{hosts + "/", schedule.KeyPrefix(hosts), audit.KeyPrefix(hosts)} {
+		if key+"/" == prefix || strings.HasPrefix(key, prefix) {
+			return fmt.Errorf("must not be under %s", prefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and its key to dir.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "etcd"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPEM, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"valid", Config{Endpoints: []string{"https://10.0.0.1:2379", "localhost:2379", "unix:///run/etcd.sock"}, CA: certFile, Cert: certFile, CertKey: keyFile}, ""},
+		{"no endpoints", Config{}, "no endpoints"},
+		{"bad scheme", Config{Endpoints: []string{"ftp://10.0.0.1"}}, "invalid endpoint"},
+		{"no host", Config{Endpoints: []string{"https://"}}, "has no host"},
+		{"missing ca", Config{Endpoints: []string{"https://a:2379"}, CA: "/nonexistent/ca.pem"}, "ca: /nonexistent/ca.pem: file not found"},
+		{"ca not pem", Config{Endpoints: []string{"https://a:2379"}, CA: notPEM}, "no PEM certificates"},
+		{"cert without key", Config{Endpoints: []string{"https://a:2379"}, Cert: certFile}, "cert_key: must be set together"},
+		{"key mismatch", Config{Endpoints: []string{"https://a:2379"}, Cert: certFile, CertKey: notPEM}, "invalid key pair"},
+		{"ptr key is hosts key", Config{Endpoints: []string{"https://a:2379"}, Key: "/etcdhosts", PTRKey: "/etcdhosts/"}, "ptr_key: must differ from key"},
+		{"ptr key under hosts key", Config{Endpoints: []string{"https://a:2379"}, Key: "/etcdhosts", PTRKey: "/etcdhosts/ptr"}, "ptr_key: must not be under /etcdhosts/"},
+		{"ptr key is audit prefix", Config{Endpoints: []string{"https://a:2379"}, Key: "/etcdhosts", PTRKey: "/etcdhosts.audit"}, "ptr_key: must not be under /etcdhosts.audit/"},
+		{"ptr key under schedule", Config{Endpoints: []string{"https://a:2379"}, Key: "/etcdhosts", PTRKey: "/etcdhosts.schedule/ptr"}, "ptr_key: must not be under /etcdhosts.schedule/"},
+		{"ptr key sibling", Config{Endpoints: []string{"https://a:2379"}, Key: "/etcdhosts", PTRKey: "/etcdhosts.ptr"}, ""},
+		{"pool", Config{Endpoints: []string{"https://a:2379"}, Pools: map[string]Pool{"lab": {CIDR: "10.0.0.0/24", Exclude: []string{"10.0.0.1"}}}}, ""},
+		{"pool bad cidr", Config{Endpoints: []string{"https://a:2379"}, Pools: map[string]Pool{"lab": {CIDR: "10.0.0.0/33"}}}, "pools.lab: invalid CIDR"},
+		{"pool exclude outside", Config{Endpoints: []string{"https://a:2379"}, Pools: map[string]Pool{"lab": {CIDR: "10.0.0.0/24", Exclude: []string{"10.0.1.0/28"}}}}, "pools.lab: exclude 10.0.1.0/28 is outside 10.0.0.0/24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_UnknownField(t *testing.T) {
+	path := writeConfig(t, "endpoints:\n  - http://localhost:2379\ncert-key: /tmp/key.pem\n")
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "cert-key") {
+		t.Errorf("Load() error = %v, want unknown field cert-key", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	content := `current-context: dev
+contexts:
+  prod:
+    endpoints:
+      - https://10.0.0.1:2379
+    ca: /nonexistent/ca.pem
+    password: secret
+  staging:
+    endpoints:
+      - http://10.1.0.1:2379
+    cert-key: /tmp/key.pem
+`
+	path := writeConfig(t, content)
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Check(path)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	want := []string{
+		"field cert-key not found",
+		`current-context: context "dev" not found`,
+		"context prod: ca: /nonexistent/ca.pem",
+		"password: " + path + " is readable by other users",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Check() = %v, want %d problems", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i].String(), w) {
+			t.Errorf("problem %d = %q, want %q", i, problems[i], w)
+		}
+	}
+	if !problems[3].Warning || problems[0].Warning {
+		t.Error("only the permission problem should be a warning")
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	problems, _ = Check(path)
+	if len(problems) != 3 {
+		t.Errorf("Check() after chmod = %v, want no permission warning", problems)
+	}
+}
+
+func TestCheck_InvalidYAML(t *testing.T) {
+	path := writeConfig(t, "invalid: yaml: content:")
+	if _, err := Check(path); err == nil {
+		t.Error("Check() with invalid YAML should return error")
+	}
+}
@@ -0,0 +1,134 @@
+package dnsprobe
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Drift states of a hostname.
+const (
+	DriftNone  = "ok"
+	DriftFound = "drift"
+	DriftError = "error"
+)
+
+// Drift is the difference between the answers of a DNS server for a
+// hostname and its records.
+type Drift struct {
+	Hostname string `json:"hostname" yaml:"hostname"`
+	Status   string `json:"status" yaml:"status"`
+	// Missing are stored addresses the server never answered with.
+	Missing []string `json:"missing,omitempty" yaml:"missing,omitempty"`
+	// Extra are answered addresses that the hostname never had.
+	Extra []string `json:"extra,omitempty" yaml:"extra,omitempty"`
+	// Stale are answered addresses that the hostname had in an earlier
+	// revision, a sign that the server serves old data.
+	Stale   []string `json:"stale,omitempty" yaml:"stale,omitempty"`
+	Samples int      `json:"samples" yaml:"samples"`
+	Error   string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// AuditOptions configure an audit.
+type AuditOptions struct {
+	// Samples is the most queries per hostname and family. Querying
+	// stops early once every stored address was answered, so a server
+	// answering with one weighted record per query is audited fully.
+	Samples int
+	// QueryTimeout limits each query.
+	QueryTimeout time.Duration
+}
+
+// Audit resolves every hostname of recs against server and reports how
+// the answers differ from the records. past holds earlier addresses of
+// hostnames, keyed by normalized hostname, to tell stale answers from
+// unknown ones.
+func Audit(ctx context.Context, server string, recs []client.Record, past map[string][]net.IP, opts AuditOptions) []Drift {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range recs {
+		if name := records.NormalizeHostname(r.Hostname); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	exps := Expect(recs, names)
+
+	drifts := make([]Drift, len(exps))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+	for i, e := range exps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			drifts[i] = audit(ctx, server, e, past[e.Hostname], opts)
+		}()
+	}
+	wg.Wait()
+	return drifts
+}
+
+func audit(ctx context.Context, server string, e Expectation, past []net.IP, opts AuditOptions) Drift {
+	d := Drift{Hostname: e.Hostname, Status: DriftNone}
+	want := append(slices.Clone(e.A), e.AAAA...)
+
+	var answered []net.IP
+	for d.Samples < max(opts.Samples, 1) {
+		d.Samples++
+		got, _, err := Compare(ctx, server, e, opts.QueryTimeout)
+		if err != nil {
+			d.Status, d.Error = DriftError, err.Error()
+			return d
+		}
+		for _, ip := range got {
+			if !slices.ContainsFunc(answered, ip.Equal) {
+				answered = append(answered, ip)
+			}
+		}
+		if !slices.ContainsFunc(want, func(ip net.IP) bool { return !slices.ContainsFunc(answered, ip.Equal) }) {
+			break
+		}
+	}
+
+	for _, ip := range want {
+		if !slices.ContainsFunc(answered, ip.Equal) {
+			d.Missing = append(d.Missing, ip.String())
+		}
+	}
+	for _, ip := range answered {
+		switch {
+		case slices.ContainsFunc(want, ip.Equal):
+		case slices.ContainsFunc(past, ip.Equal):
+			d.Stale = append(d.Stale, ip.String())
+		default:
+			d.Extra = append(d.Extra, ip.String())
+		}
+	}
+	if len(d.Missing)+len(d.Extra)+len(d.Stale) > 0 {
+		d.Status = DriftFound
+	}
+	return d
+}
+
+// PastAddresses returns the addresses each hostname had in versions,
+// keyed by normalized hostname.
+func PastAddresses(versions []*client.Hosts) map[string][]net.IP {
+	past := make(map[string][]net.IP)
+	for _, h := range versions {
+		for _, r := range h.Records() {
+			name := records.NormalizeHostname(r.Hostname)
+			if !slices.ContainsFunc(past[name], r.IP.Equal) {
+				past[name] = append(past[name], r.IP)
+			}
+		}
+	}
+	return past
+}
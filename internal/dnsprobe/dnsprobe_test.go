@@ -0,0 +1,334 @@
+package dnsprobe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDNS is an in-process DNS server answering A and AAAA queries from
+// a table that tests can change while it runs.
+type fakeDNS struct {
+	addr string
+
+	mu       sync.Mutex
+	names    map[string][]net.IP
+	truncate bool
+	// single answers with one address per A query, taking turns.
+	single  bool
+	turn    int
+	queries int
+}
+
+func startFakeDNS(t *testing.T, names map[string][]net.IP) *fakeDNS {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = pc.Close(); _ = l.Close() })
+
+	f := &fakeDNS{addr: pc.LocalAddr().String(), names: names}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, peer, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(f.answer(buf[:n], true), peer)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var size [2]byte
+			if _, err := io.ReadFull(conn, size[:]); err == nil {
+				msg := make([]byte, binary.BigEndian.Uint16(size[:]))
+				if _, err := io.ReadFull(conn, msg); err == nil {
+					resp := f.answer(msg, false)
+					_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+	return f
+}
+
+func (f *fakeDNS) set(name string, ips ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(ips) == 0 {
+		delete(f.names, name)
+		return
+	}
+	f.names[name] = nil
+	for _, ip := range ips {
+		f.names[name] = append(f.names[name], net.ParseIP(ip))
+	}
+}
+
+func (f *fakeDNS) answer(msg []byte, udp bool) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+
+	var query dnsmessage.Message
+	if err := query.Unpack(msg); err != nil || len(query.Questions) != 1 {
+		return nil
+	}
+	q := query.Questions[0]
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+		Questions: query.Questions,
+	}
+	ips, ok := f.names[strings.ToLower(q.Name.String())]
+	switch {
+	case !ok:
+		resp.RCode = dnsmessage.RCodeNameError
+	case udp && f.truncate:
+		resp.Truncated = true
+	default:
+		if f.single && q.Type == dnsmessage.TypeA {
+			f.turn++
+			ips = []net.IP{ips[f.turn%len(ips)]}
+		}
+		for _, ip := range ips {
+			hdr := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+			if v4 := ip.To4(); v4 != nil && q.Type == dnsmessage.TypeA {
+				hdr.Type = dnsmessage.TypeA
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: hdr, Body: &dnsmessage.AResource{A: [4]byte(v4)}})
+			} else if v4 == nil && q.Type == dnsmessage.TypeAAAA {
+				hdr.Type = dnsmessage.TypeAAAA
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: hdr, Body: &dnsmessage.AAAAResource{AAAA: [16]byte(ip.To16())}})
+			}
+		}
+	}
+	packed, _ := resp.Pack()
+	return packed
+}
+
+func TestQuery(t *testing.T) {
+	f := startFakeDNS(t, map[string][]net.IP{
+		"api.local.": {net.ParseIP("10.0.0.1"), net.ParseIP("2001:db8::1")},
+	})
+	ctx := context.Background()
+
+	ips, err := Query(ctx, f.addr, "api.local.", dnsmessage.TypeA)
+	if err != nil || len(ips) != 1 || ips[0].String() != "10.0.0.1" {
+		t.Errorf("Query(A) = %v, %v", ips, err)
+	}
+	ips, err = Query(ctx, f.addr, "api.local.", dnsmessage.TypeAAAA)
+	if err != nil || len(ips) != 1 || ips[0].String() != "2001:db8::1" {
+		t.Errorf("Query(AAAA) = %v, %v", ips, err)
+	}
+	if _, err := Query(ctx, f.addr, "nope.local.", dnsmessage.TypeA); !errors.Is(err, ErrNXDomain) {
+		t.Errorf("Query(nope) error = %v, want ErrNXDomain", err)
+	}
+
+	f.mu.Lock()
+	f.truncate = true
+	f.mu.Unlock()
+	ips, err = Query(ctx, f.addr, "api.local.", dnsmessage.TypeA)
+	if err != nil || len(ips) != 1 {
+		t.Errorf("Query(A) over TCP = %v, %v", ips, err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1:5353": "127.0.0.1:5353",
+		"127.0.0.1":      "127.0.0.1:53",
+		"::1":            "[::1]:53",
+		"[::1]:5353":     "[::1]:5353",
+		"dns.local":      "dns.local:53",
+	}
+	for in, want := range tests {
+		if got, err := Server(in); err != nil || got != want {
+			t.Errorf("Server(%s) = %s, %v, want %s", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "a:b:c"} {
+		if _, err := Server(in); err == nil {
+			t.Errorf("Server(%q) should fail", in)
+		}
+	}
+}
+
+func TestChangedAndExpect(t *testing.T) {
+	oldRecs := []client.Record{
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1")},
+		{Hostname: "web.local.", IP: net.ParseIP("10.0.0.2")},
+		{Hostname: "old.local.", IP: net.ParseIP("10.0.0.3")},
+	}
+	newRecs := []client.Record{
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1"), Weight: 5},
+		{Hostname: "web.local.", IP: net.ParseIP("10.0.0.4")},
+		{Hostname: "new.local.", IP: net.ParseIP("2001:db8::1")},
+	}
+	changed := Changed(oldRecs, newRecs)
+	if want := []string{"new.local.", "old.local.", "web.local."}; !slices.Equal(changed, want) {
+		t.Errorf("Changed() = %v, want %v", changed, want)
+	}
+
+	exps := Expect(newRecs, changed)
+	if len(exps[0].AAAA) != 1 || len(exps[1].A)+len(exps[1].AAAA) != 0 || exps[2].A[0].String() != "10.0.0.4" {
+		t.Errorf("Expect() = %+v", exps)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f := startFakeDNS(t, map[string][]net.IP{
+		"api.local.": {net.ParseIP("10.0.0.1")},
+		"old.local.": {net.ParseIP("10.0.0.3")},
+	})
+	exps := []Expectation{
+		{Hostname: "api.local.", A: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}},
+		{Hostname: "new.local.", A: []net.IP{net.ParseIP("10.0.0.5")}, AAAA: []net.IP{net.ParseIP("2001:db8::5")}},
+		{Hostname: "old.local."},
+	}
+	opts := Options{Timeout: 100 * time.Millisecond, Interval: 20 * time.Millisecond, QueryTimeout: time.Second}
+
+	// Answering with only some of the records is not a match.
+	results := Verify(context.Background(), f.addr, exps[:1], opts)
+	if r := results[0]; r.Status != StatusMismatch || r.Detail != "missing A 10.0.0.2" {
+		t.Errorf("Verify(api.local.) = %s %q, want 10.0.0.2 missing", r.Status, r.Detail)
+	}
+
+	// The server picks up the change shortly after the write.
+	time.AfterFunc(100*time.Millisecond, func() {
+		f.set("api.local.", "10.0.0.1", "10.0.0.2")
+		f.set("new.local.", "10.0.0.5", "2001:db8::5")
+		f.set("old.local.")
+	})
+	opts.Timeout = 2 * time.Second
+	results = Verify(context.Background(), f.addr, exps, opts)
+	for _, r := range results {
+		if r.Status != StatusOK {
+			t.Errorf("Verify(%s) = %s %q, want ok", r.Hostname, r.Status, r.Detail)
+		}
+	}
+	for _, i := range []int{0, 1} {
+		if results[i].Attempts < 2 {
+			t.Errorf("Verify(%s) took %d attempts, want it to poll", results[i].Hostname, results[i].Attempts)
+		}
+	}
+
+	// Answers are collected across polls.
+	f.mu.Lock()
+	f.single = true
+	f.mu.Unlock()
+	results = Verify(context.Background(), f.addr, exps[:1], opts)
+	if r := results[0]; r.Status != StatusOK || r.Attempts != 2 {
+		t.Errorf("Verify(api.local.) with single answers = %s %q after %d attempts, want ok after 2", r.Status, r.Detail, r.Attempts)
+	}
+	f.mu.Lock()
+	f.single = false
+	f.mu.Unlock()
+
+	f.set("api.local.", "10.0.0.9")
+	opts.Timeout = 100 * time.Millisecond
+	results = Verify(context.Background(), f.addr, exps[:1], opts)
+	if r := results[0]; r.Status != StatusMismatch || r.Detail != "unexpected A 10.0.0.9" || len(Failed(results)) != 1 {
+		t.Errorf("Verify(api.local.) = %s %q, want a mismatch", r.Status, r.Detail)
+	}
+
+	results = Verify(context.Background(), closedUDP(t), exps[:1], opts)
+	if r := results[0]; r.Status != StatusError {
+		t.Errorf("Verify() without a server = %s %q, want an error", r.Status, r.Detail)
+	}
+}
+
+// closedUDP returns a local address with no DNS server behind it.
+func closedUDP(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := pc.LocalAddr().String()
+	_ = pc.Close()
+	return addr
+}
+
+func TestAudit(t *testing.T) {
+	f := startFakeDNS(t, map[string][]net.IP{
+		"api.local.":   {net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+		"web.local.":   {net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.9"), net.ParseIP("10.0.0.8")},
+		"mixed.local.": {net.ParseIP("10.0.0.4")},
+	})
+	recs := []client.Record{
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1")},
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.2")},
+		{Hostname: "web.local.", IP: net.ParseIP("10.0.0.3")},
+		{Hostname: "mixed.local.", IP: net.ParseIP("10.0.0.4")},
+		{Hostname: "mixed.local.", IP: net.ParseIP("2001:db8::4")},
+		{Hostname: "new.local.", IP: net.ParseIP("10.0.0.5")},
+	}
+	past := map[string][]net.IP{"web.local.": {net.ParseIP("10.0.0.9")}}
+	opts := AuditOptions{Samples: 10, QueryTimeout: time.Second}
+
+	drifts := Audit(context.Background(), f.addr, recs, past, opts)
+	got := make(map[string]Drift)
+	for _, d := range drifts {
+		got[d.Hostname] = d
+	}
+	if d := got["api.local."]; d.Status != DriftNone || d.Samples != 1 {
+		t.Errorf("api.local. = %+v, want ok after one sample", d)
+	}
+	if d := got["web.local."]; d.Status != DriftFound || !slices.Equal(d.Stale, []string{"10.0.0.9"}) || !slices.Equal(d.Extra, []string{"10.0.0.8"}) {
+		t.Errorf("web.local. = %+v, want stale 10.0.0.9 and extra 10.0.0.8", d)
+	}
+	if d := got["mixed.local."]; d.Status != DriftFound || !slices.Equal(d.Missing, []string{"2001:db8::4"}) || d.Samples != 10 {
+		t.Errorf("mixed.local. = %+v, want 2001:db8::4 missing after all samples", d)
+	}
+	if d := got["new.local."]; d.Status != DriftFound || !slices.Equal(d.Missing, []string{"10.0.0.5"}) {
+		t.Errorf("new.local. = %+v, want 10.0.0.5 missing", d)
+	}
+
+	// A server answering with one address per query is sampled until
+	// every stored address was seen.
+	f.mu.Lock()
+	f.single = true
+	f.mu.Unlock()
+	drifts = Audit(context.Background(), f.addr, recs[:2], nil, opts)
+	if d := drifts[0]; d.Status != DriftNone || d.Samples < 2 {
+		t.Errorf("api.local. with single answers = %+v, want ok", d)
+	}
+
+	drifts = Audit(context.Background(), closedUDP(t), recs[:1], nil, AuditOptions{Samples: 1, QueryTimeout: 100 * time.Millisecond})
+	if d := drifts[0]; d.Status != DriftError || d.Error == "" {
+		t.Errorf("Audit() without a server = %+v, want an error", d)
+	}
+}
+
+func TestPastAddresses(t *testing.T) {
+	v1 := client.NewHosts()
+	_ = v1.Add(client.Record{Hostname: "api.local", IP: net.ParseIP("10.0.0.1")})
+	v2 := client.NewHosts()
+	_ = v2.Add(client.Record{Hostname: "api.local", IP: net.ParseIP("10.0.0.1")})
+	_ = v2.Add(client.Record{Hostname: "api.local", IP: net.ParseIP("10.0.0.2")})
+
+	past := PastAddresses([]*client.Hosts{v1, v2})
+	if got := ipStrings(past["api.local."]); !slices.Equal(got, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("PastAddresses() = %v", got)
+	}
+}
@@ -0,0 +1,143 @@
+// Package dnsprobe queries a DNS server for the records of hostnames and
+// compares its answers with the records stored in etcd.
+package dnsprobe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ErrNXDomain is returned by Query for names that do not exist.
+var ErrNXDomain = errors.New("no such domain")
+
+// Server returns addr with the DNS port added if it has none.
+func Server(addr string) (string, error) {
+	if addr == "" {
+		return "", errors.New("empty DNS server address")
+	}
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr, nil
+	}
+	if ip := net.ParseIP(strings.Trim(addr, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), "53"), nil
+	}
+	if strings.Contains(addr, ":") {
+		return "", fmt.Errorf("invalid DNS server address %q (expected HOST:PORT)", addr)
+	}
+	return net.JoinHostPort(addr, "53"), nil
+}
+
+// Query asks server for the A or AAAA records of hostname and returns
+// the addresses in the answer. It uses UDP and retries over TCP if the
+// answer is truncated. A name that does not exist gives ErrNXDomain; a
+// name without records of the type gives no addresses and no error.
+func Query(ctx context.Context, server, hostname string, typ dnsmessage.Type) ([]net.IP, error) {
+	name, err := dnsmessage.NewName(hostname)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostname %q: %w", hostname, err)
+	}
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: typ, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := exchange(ctx, "udp", server, packed, query.ID)
+	if err == nil && resp.Truncated {
+		resp, err = exchange(ctx, "tcp", server, packed, query.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, ErrNXDomain
+	default:
+		return nil, fmt.Errorf("server answered %s", resp.RCode)
+	}
+
+	var ips []net.IP
+	for _, rr := range resp.Answers {
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(body.AAAA[:]))
+		}
+	}
+	return ips, nil
+}
+
+// exchange sends a packed query over network and returns the response
+// with the given ID.
+func exchange(ctx context.Context, network, server string, packed []byte, id uint16) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	tcp := network == "tcp"
+	if tcp {
+		packed = append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...)
+	}
+	if _, err := conn.Write(packed); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+
+	for {
+		buf, err := readMessage(conn, tcp)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf); err != nil || !resp.Response || resp.ID != id {
+			continue
+		}
+		return &resp, nil
+	}
+}
+
+// readMessage reads one DNS message, which over TCP has a two byte
+// length prefix.
+func readMessage(conn net.Conn, tcp bool) ([]byte, error) {
+	if !tcp {
+		buf := make([]byte, 65535)
+		n, err := conn.Read(buf)
+		return buf[:n], err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+	_, err := io.ReadFull(conn, buf)
+	return buf, err
+}
+
+// ctxErr returns the error of ctx if it is done, which caused err.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package dnsprobe
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Results are the outcomes of a verification.
+type Results []Result
+
+// Table implements output.Tabular.
+func (rs Results) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "HOSTNAME"}, {Name: "STATUS"}, {Name: "ATTEMPTS"}, {Name: "ELAPSED"}, {Name: "DETAIL"},
+		{Name: "EXPECTED", Wide: true}, {Name: "ANSWERS", Wide: true},
+	}}
+	for _, r := range rs {
+		t.Rows = append(t.Rows, []string{
+			r.Hostname,
+			string(r.Status),
+			strconv.Itoa(r.Attempts),
+			(time.Duration(r.ElapsedMS) * time.Millisecond).String(),
+			output.OrDash(r.Detail),
+			output.OrDash(strings.Join(r.Expected, " ")),
+			output.OrDash(strings.Join(r.Answers, " ")),
+		})
+	}
+	return t
+}
+
+// Drifts are the findings of an audit.
+type Drifts []Drift
+
+// Table implements output.Tabular.
+func (ds Drifts) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "HOSTNAME"}, {Name: "STATUS"}, {Name: "MISSING"}, {Name: "EXTRA"}, {Name: "STALE"},
+		{Name: "SAMPLES", Wide: true}, {Name: "ERROR", Wide: true},
+	}}
+	for _, d := range ds {
+		t.Rows = append(t.Rows, []string{
+			d.Hostname,
+			d.Status,
+			output.OrDash(strings.Join(d.Missing, " ")),
+			output.OrDash(strings.Join(d.Extra, " ")),
+			output.OrDash(strings.Join(d.Stale, " ")),
+			strconv.Itoa(d.Samples),
+			output.OrDash(d.Error),
+		})
+	}
+	return t
+}
@@ -0,0 +1,255 @@
+package dnsprobe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Status is the outcome of the verification of a hostname.
+type Status string
+
+const (
+	// StatusOK means the answers match the records.
+	StatusOK Status = "ok"
+	// StatusMismatch means the answers still differ when time ran out.
+	StatusMismatch Status = "mismatch"
+	// StatusError means the server could not be queried.
+	StatusError Status = "error"
+)
+
+// Expectation is the addresses a hostname should resolve to. A hostname
+// without addresses should not resolve at all.
+type Expectation struct {
+	Hostname string
+	A        []net.IP
+	AAAA     []net.IP
+}
+
+// Expect returns the expectations for hostnames according to recs.
+func Expect(recs []client.Record, hostnames []string) []Expectation {
+	byName := make(map[string]*Expectation, len(hostnames))
+	out := make([]Expectation, len(hostnames))
+	for i, h := range hostnames {
+		out[i].Hostname = records.NormalizeHostname(h)
+		byName[out[i].Hostname] = &out[i]
+	}
+	for _, r := range recs {
+		e, ok := byName[records.NormalizeHostname(r.Hostname)]
+		if !ok {
+			continue
+		}
+		if records.Family(r) == "v6" {
+			e.AAAA = append(e.AAAA, r.IP)
+		} else {
+			e.A = append(e.A, r.IP)
+		}
+	}
+	return out
+}
+
+// Changed returns the sorted hostnames whose records differ between
+// oldRecs and newRecs, including hostnames added or removed.
+func Changed(oldRecs, newRecs []client.Record) []string {
+	set := func(recs []client.Record) map[string][]string {
+		m := make(map[string][]string)
+		for _, r := range recs {
+			name := records.NormalizeHostname(r.Hostname)
+			m[name] = append(m[name], records.Key(r))
+		}
+		for _, keys := range m {
+			slices.Sort(keys)
+		}
+		return m
+	}
+	before, after := set(oldRecs), set(newRecs)
+
+	var changed []string
+	for name, keys := range after {
+		if !slices.Equal(keys, before[name]) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Options configure a verification.
+type Options struct {
+	// Timeout is how long to wait for the answers to match.
+	Timeout time.Duration
+	// Interval is the time between queries of a hostname.
+	Interval time.Duration
+	// QueryTimeout limits each query.
+	QueryTimeout time.Duration
+}
+
+// Result is the outcome of the verification of one hostname.
+type Result struct {
+	Hostname  string   `json:"hostname" yaml:"hostname"`
+	Status    Status   `json:"status" yaml:"status"`
+	Attempts  int      `json:"attempts" yaml:"attempts"`
+	ElapsedMS int64    `json:"elapsed_ms" yaml:"elapsed_ms"`
+	Expected  []string `json:"expected" yaml:"expected"`
+	Answers   []string `json:"answers" yaml:"answers"`
+	Detail    string   `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// maxParallel limits the hostnames verified at the same time.
+const maxParallel = 16
+
+// Verify queries server for the A and AAAA records of each expectation
+// until the answers match or opts.Timeout expires. Answers match once
+// every record has been answered and no other address was, with nothing
+// answered in families the hostname has no records in. Like Audit,
+// answers are collected across polls, so a server that answers with one
+// of several records per query matches once it returned each of them.
+// An unexpected answer starts the collection over, as the server still
+// serves old data.
+func Verify(ctx context.Context, server string, exps []Expectation, opts Options) []Result {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	results := make([]Result, len(exps))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+	for i, e := range exps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = verify(ctx, server, e, opts)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func verify(ctx context.Context, server string, e Expectation, opts Options) Result {
+	res := Result{Hostname: e.Hostname, Expected: ipStrings(append(slices.Clone(e.A), e.AAAA...))}
+	start := time.Now()
+	var answered []net.IP
+	for {
+		res.Attempts++
+		answers, detail, err := Compare(ctx, server, e, opts.QueryTimeout)
+		res.ElapsedMS = time.Since(start).Milliseconds()
+		res.Answers = ipStrings(answers)
+		if err == nil {
+			if detail != "" {
+				answered = nil
+			}
+			for _, ip := range answers {
+				if !slices.ContainsFunc(answered, ip.Equal) {
+					answered = append(answered, ip)
+				}
+			}
+			if detail == "" {
+				detail = missing(e, answered)
+			}
+		}
+		switch {
+		case err == nil && detail == "":
+			res.Status, res.Detail = StatusOK, ""
+			return res
+		case err != nil:
+			res.Status, res.Detail = StatusError, err.Error()
+		default:
+			res.Status, res.Detail = StatusMismatch, detail
+		}
+
+		select {
+		case <-ctx.Done():
+			if res.Status == StatusError && errors.Is(err, context.DeadlineExceeded) {
+				res.Detail = fmt.Sprintf("no answer within %s", opts.Timeout)
+			}
+			return res
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// Compare queries server once for the A and AAAA records of e and
+// returns the answers with a description of the unexpected ones and of
+// families without an answer, empty if there are none. Records of e that
+// were not answered are not reported, as a server may answer with only
+// some of them per query.
+func Compare(ctx context.Context, server string, e Expectation, timeout time.Duration) ([]net.IP, string, error) {
+	var answers []net.IP
+	var diffs []string
+	for _, q := range []struct {
+		typ  dnsmessage.Type
+		want []net.IP
+	}{{dnsmessage.TypeA, e.A}, {dnsmessage.TypeAAAA, e.AAAA}} {
+		qctx, cancel := context.WithTimeout(ctx, timeout)
+		got, err := Query(qctx, server, e.Hostname, q.typ)
+		cancel()
+		if err != nil && !errors.Is(err, ErrNXDomain) {
+			return answers, "", err
+		}
+		answers = append(answers, got...)
+
+		typ := strings.TrimPrefix(q.typ.String(), "Type")
+		if len(q.want) > 0 && len(got) == 0 {
+			diffs = append(diffs, "no "+typ+" answer")
+		}
+		for _, ip := range got {
+			if !slices.ContainsFunc(q.want, ip.Equal) {
+				diffs = append(diffs, "unexpected "+typ+" "+ip.String())
+			}
+		}
+	}
+	return answers, strings.Join(diffs, ", "), nil
+}
+
+// missing describes the records of e that are not in answered, empty if
+// every record was answered.
+func missing(e Expectation, answered []net.IP) string {
+	var diffs []string
+	for _, q := range []struct {
+		typ  string
+		want []net.IP
+	}{{"A", e.A}, {"AAAA", e.AAAA}} {
+		for _, ip := range q.want {
+			if !slices.ContainsFunc(answered, ip.Equal) {
+				diffs = append(diffs, "missing "+q.typ+" "+ip.String())
+			}
+		}
+	}
+	return strings.Join(diffs, ", ")
+}
+
+func ipStrings(ips []net.IP) []string {
+	out := make([]string, len(ips))
+	for i, ip := range ips {
+		out[i] = ip.String()
+	}
+	return out
+}
+
+// Failed returns the results that did not match.
+func Failed(results []Result) []Result {
+	var out []Result
+	for _, r := range results {
+		if r.Status != StatusOK {
+			out = append(out, r)
+		}
+	}
+	return out
+}
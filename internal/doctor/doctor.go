@@ -0,0 +1,398 @@
+// Package doctor diagnoses the connection to etcd and the stored hosts data.
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/config"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+const (
+	// SlowLatency is the status request latency above which an endpoint
+	// is reported as slow.
+	SlowLatency = 200 * time.Millisecond
+
+	// CertExpiryWarning is how long before expiry a certificate is reported.
+	CertExpiryWarning = 30 * 24 * time.Hour
+
+	metaKey = ".meta"
+	// lockKey holds the keys of the write mutex of the client, one per
+	// lease under lockKey/.
+	lockKey = "lock"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Check  string `json:"check" yaml:"check"`
+	Target string `json:"target" yaml:"target"`
+	Status Status `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+
+	// Lines lists the findings of a check, such as the invalid lines of
+	// the stored data.
+	Lines []string `json:"lines,omitempty" yaml:"lines,omitempty"`
+}
+
+// Count returns the number of results with each status.
+func Count(results []Result) map[Status]int {
+	counts := make(map[Status]int, 3)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// ClientCert checks the expiry of the configured client certificate.
+func ClientCert(cfg *config.Config) []Result {
+	if cfg.Cert == "" || cfg.CertKey == "" {
+		return nil
+	}
+	tlsCfg, err := cfg.TLSConfig()
+	if err != nil {
+		return []Result{{Check: "client cert", Target: cfg.Cert, Status: StatusFail, Detail: err.Error()}}
+	}
+	return []Result{certExpiry("client cert", cfg.Cert, tlsCfg.Certificates[0].Leaf, time.Now())}
+}
+
+// Endpoint checks a single etcd endpoint: reachability, TLS handshake and
+// server certificate expiry, authentication, latency, etcd version and
+// leader. Checks that depend on a failed one are skipped.
+func Endpoint(ctx context.Context, cfg *config.Config, endpoint string) []Result {
+	var results []Result
+	add := func(check string, status Status, format string, args ...any) {
+		results = append(results, Result{Check: check, Target: endpoint, Status: status, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	network, addr, secure, err := dialAddress(endpoint)
+	if err != nil {
+		add("reachable", StatusFail, "%v", err)
+		return results
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(dialCtx, network, addr)
+	if err != nil {
+		add("reachable", StatusFail, "%v", err)
+		return results
+	}
+	add("reachable", StatusPass, "connected in %s", roundDuration(time.Since(start)))
+
+	var tlsCfg *tls.Config
+	if secure {
+		tlsCfg, err = serverTLSConfig(cfg, addr)
+		if err != nil {
+			_ = conn.Close()
+			add("tls", StatusFail, "%v", err)
+			return results
+		}
+		tlsConn := tls.Client(conn, tlsCfg)
+		if err := tlsConn.HandshakeContext(dialCtx); err != nil {
+			_ = conn.Close()
+			add("tls", StatusFail, "handshake failed: %v", err)
+			return results
+		}
+		leaf := tlsConn.ConnectionState().PeerCertificates[0]
+		results = append(results, certExpiry("tls", endpoint, leaf, time.Now()))
+	}
+	_ = conn.Close()
+
+	etcdCfg, err := cfg.ToEtcdConfig()
+	if err != nil {
+		add("connect", StatusFail, "%v", err)
+		return results
+	}
+	etcdCfg.Endpoints = []string{endpoint}
+	etcdCfg.Logger = zap.NewNop()
+	if etcdCfg.TLS == nil {
+		etcdCfg.TLS = tlsCfg
+	}
+	cli, err := clientv3.New(etcdCfg)
+	if err != nil {
+		check := "connect"
+		if cfg.Username != "" && strings.Contains(err.Error(), "authenticat") {
+			check = "auth"
+		}
+		add(check, StatusFail, "%v", err)
+		return results
+	}
+	defer func() { _ = cli.Close() }()
+	if cfg.Username != "" {
+		add("auth", StatusPass, "authenticated as %s", cfg.Username)
+	}
+
+	reqCtx, cancelReq := context.WithTimeout(ctx, cfg.ReqTimeout)
+	defer cancelReq()
+	start = time.Now()
+	status, err := cli.Status(reqCtx, endpoint)
+	latency := time.Since(start)
+	if err != nil {
+		add("status", StatusFail, "%v", err)
+		return results
+	}
+
+	if latency > SlowLatency {
+		add("latency", StatusWarn, "status request took %s (> %s)", roundDuration(latency), SlowLatency)
+	} else {
+		add("latency", StatusPass, "status request took %s", roundDuration(latency))
+	}
+	add("version", StatusPass, "etcd %s, db size %s", status.Version, formatBytes(status.DbSize))
+
+	switch {
+	case status.Leader == 0:
+		add("leader", StatusFail, "no leader")
+	case status.Leader == status.Header.MemberId:
+		add("leader", StatusPass, "this member (%x) is the leader, raft term %d", status.Leader, status.RaftTerm)
+	default:
+		add("leader", StatusPass, "leader %x, raft term %d", status.Leader, status.RaftTerm)
+	}
+	if len(status.Errors) > 0 {
+		add("alarms", StatusWarn, "%s", strings.Join(status.Errors, "; "))
+	}
+	return results
+}
+
+// Data checks that the hosts key exists, reports the storage mode and
+// parses the stored data strictly, listing every invalid line.
+func Data(ctx context.Context, kv clientv3.KV, key string) []Result {
+	var results []Result
+	add := func(check string, status Status, format string, args ...any) *Result {
+		results = append(results, Result{Check: check, Target: key, Status: status, Detail: fmt.Sprintf(format, args...)})
+		return &results[len(results)-1]
+	}
+
+	metaResp, err := kv.Get(ctx, key+"/"+metaKey)
+	if err != nil {
+		add("key", StatusFail, "%v", err)
+		return results
+	}
+	singleResp, err := kv.Get(ctx, key)
+	if err != nil {
+		add("key", StatusFail, "%v", err)
+		return results
+	}
+	prefixResp, err := kv.Get(ctx, key+"/", clientv3.WithPrefix())
+	if err != nil {
+		add("key", StatusFail, "%v", err)
+		return results
+	}
+
+	var domains []hostKey
+	for _, kv := range prefixResp.Kvs {
+		name := strings.TrimPrefix(string(kv.Key), key+"/")
+		if name != metaKey && name != lockKey && !strings.HasPrefix(name, lockKey+"/") {
+			domains = append(domains, hostKey{name: name, value: kv.Value})
+		}
+	}
+
+	var mode client.StorageMode
+	var source string
+	switch {
+	case len(metaResp.Kvs) > 0:
+		var meta client.Meta
+		if err := json.Unmarshal(metaResp.Kvs[0].Value, &meta); err != nil {
+			add("mode", StatusFail, "invalid meta key: %v", err)
+			return results
+		}
+		mode, source = meta.Mode, "from meta key"
+	case len(singleResp.Kvs) > 0:
+		mode, source = client.ModeSingle, "detected, meta key not set"
+	case len(domains) > 0:
+		mode, source = client.ModePerHost, "detected, meta key not set"
+	default:
+		add("key", StatusFail, "key %s does not exist", key)
+		return results
+	}
+
+	switch mode {
+	case client.ModeSingle:
+		if len(singleResp.Kvs) == 0 {
+			add("key", StatusWarn, "key %s does not exist yet", key)
+			add("mode", StatusPass, "single (%s)", source)
+			return results
+		}
+		kv := singleResp.Kvs[0]
+		add("key", StatusPass, "%s, version %d, revision %d", formatBytes(int64(len(kv.Value))), kv.Version, kv.ModRevision)
+		add("mode", StatusPass, "single (%s)", source)
+		if len(domains) > 0 {
+			add("mode", StatusWarn, "%d per-host key(s) under %s/ are ignored in single mode", len(domains), key)
+		}
+
+		parsed := client.ParseRecordsStrict(kv.Value)
+		if !parsed.HasErrors() {
+			add("data", StatusPass, "%d records", len(parsed.Records))
+		} else {
+			r := add("data", StatusFail, "%d invalid line(s), %d valid records", len(parsed.Errors), len(parsed.Records))
+			for _, e := range parsed.Errors {
+				r.Lines = append(r.Lines, e.String())
+			}
+		}
+		results = append(results, stackCheck(key, parsed.Records))
+
+	case client.ModePerHost:
+		if len(domains) == 0 {
+			add("key", StatusWarn, "no per-host keys under %s/ yet", key)
+			add("mode", StatusPass, "perhost (%s)", source)
+			return results
+		}
+		add("key", StatusPass, "%d per-host key(s)", len(domains))
+		add("mode", StatusPass, "perhost (%s)", source)
+		if len(singleResp.Kvs) > 0 {
+			add("mode", StatusWarn, "key %s is ignored in perhost mode", key)
+		}
+
+		var lines []string
+		var recs []client.Record
+		for _, d := range domains {
+			parsed := client.ParseRecordsStrict(d.value)
+			recs = append(recs, parsed.Records...)
+			for _, e := range parsed.Errors {
+				lines = append(lines, d.name+": "+e.String())
+			}
+		}
+		if len(lines) == 0 {
+			add("data", StatusPass, "%d records", len(recs))
+		} else {
+			r := add("data", StatusFail, "%d invalid line(s), %d valid records", len(lines), len(recs))
+			r.Lines = lines
+		}
+		results = append(results, stackCheck(key, recs))
+
+	default:
+		add("mode", StatusFail, "unknown storage mode %q", mode)
+	}
+	return results
+}
+
+// stackCheck counts the hostnames per address family stack. If the data
+// uses both families, hostnames with records in only one are a warning:
+// they are usually a missing A or AAAA record.
+func stackCheck(key string, recs []client.Record) Result {
+	r := Result{Check: "stack", Target: key, Status: StatusPass}
+	var dual, v4, v6 int
+	for _, f := range records.FamiliesOf(recs) {
+		switch {
+		case f.V4 && f.V6:
+			dual++
+		case f.V4:
+			v4++
+		default:
+			v6++
+		}
+	}
+	r.Detail = fmt.Sprintf("%d dual-stack, %d IPv4-only, %d IPv6-only hostname(s)", dual, v4, v6)
+	if missing := records.MissingFamilies(recs); len(missing) > 0 {
+		r.Status = StatusWarn
+		r.Detail = fmt.Sprintf("%d hostname(s) missing an address family; %s", len(missing), r.Detail)
+		r.Lines = missing
+	}
+	return r
+}
+
+// hostKey is a per-host key below the hosts key.
+type hostKey struct {
+	name  string
+	value []byte
+}
+
+// certExpiry reports whether a certificate is valid for long enough.
+func certExpiry(check, target string, cert *x509.Certificate, now time.Time) Result {
+	r := Result{Check: check, Target: target, Status: StatusPass}
+	left := cert.NotAfter.Sub(now)
+	expires := cert.NotAfter.Format("2006-01-02")
+
+	switch {
+	case now.Before(cert.NotBefore):
+		r.Status = StatusFail
+		r.Detail = fmt.Sprintf("certificate %q is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format("2006-01-02"))
+	case left <= 0:
+		r.Status = StatusFail
+		r.Detail = fmt.Sprintf("certificate %q expired on %s", cert.Subject.CommonName, expires)
+	case left < CertExpiryWarning:
+		r.Status = StatusWarn
+		r.Detail = fmt.Sprintf("certificate %q expires in %d days (%s)", cert.Subject.CommonName, int(left.Hours()/24), expires)
+	default:
+		r.Detail = fmt.Sprintf("certificate %q valid until %s", cert.Subject.CommonName, expires)
+	}
+	return r
+}
+
+// dialAddress returns the network and address to dial for an endpoint and
+// whether it uses TLS.
+func dialAddress(endpoint string) (network, addr string, secure bool, err error) {
+	if !strings.Contains(endpoint, "://") {
+		return "tcp", endpoint, false, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", false, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		addr = u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "2379")
+		}
+		return "tcp", addr, u.Scheme == "https", nil
+	case "unix", "unixs":
+		addr = u.Host + u.Path
+		return "unix", addr, u.Scheme == "unixs", nil
+	}
+	return "", "", false, fmt.Errorf("unsupported endpoint scheme %q", u.Scheme)
+}
+
+// serverTLSConfig returns the TLS config to verify an endpoint with.
+func serverTLSConfig(cfg *config.Config, addr string) (*tls.Config, error) {
+	tlsCfg, err := cfg.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		tlsCfg.ServerName = host
+	}
+	return tlsCfg, nil
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(100 * time.Microsecond)
+}
+
+// formatBytes formats a size like 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,176 @@
+package doctor
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeKV serves Get requests from a map. Other methods are not used.
+type fakeKV struct {
+	clientv3.KV
+	data map[string]string
+}
+
+func (f *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	op := clientv3.OpGet(key, opts...)
+	resp := &clientv3.GetResponse{}
+	for k, v := range f.data {
+		match := k == key
+		if op.IsOptsWithPrefix() {
+			match = strings.HasPrefix(k, key)
+		}
+		if match {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v), Version: 1, ModRevision: 10})
+		}
+	}
+	return resp, nil
+}
+
+func find(t *testing.T, results []Result, check string) Result {
+	t.Helper()
+	for _, r := range results {
+		if r.Check == check {
+			return r
+		}
+	}
+	t.Fatalf("no %s result in %v", check, results)
+	return Result{}
+}
+
+func TestData_Single(t *testing.T) {
+	kv := &fakeKV{data: map[string]string{
+		"/etcdhosts":       "10.0.0.1 a.local\nnot-an-ip b.local\n10.0.0.3\n",
+		"/etcdhosts/.meta": `{"mode":"single"}`,
+		// The write mutex of a running dnsctl is not a per-host key.
+		"/etcdhosts/lock/694d9a1c2b3e4f01": "",
+	}}
+	results := Data(context.Background(), kv, "/etcdhosts")
+
+	for _, r := range results {
+		if r.Check == "mode" && r.Status != StatusPass {
+			t.Errorf("mode = %+v, want no per-host keys with a lock held", r)
+		}
+	}
+	if r := find(t, results, "mode"); r.Status != StatusPass || !strings.Contains(r.Detail, "single (from meta key)") {
+		t.Errorf("mode = %+v", r)
+	}
+	data := find(t, results, "data")
+	if data.Status != StatusFail || len(data.Lines) != 2 {
+		t.Fatalf("data = %+v, want 2 invalid lines", data)
+	}
+	if !strings.HasPrefix(data.Lines[0], "line 2:") || !strings.HasPrefix(data.Lines[1], "line 3:") {
+		t.Errorf("Lines = %v", data.Lines)
+	}
+}
+
+func TestData_PerHost(t *testing.T) {
+	kv := &fakeKV{data: map[string]string{
+		"/dns/a.local.":              "10.0.0.1 a.local\n",
+		"/dns/b.local.":              "10.0.0.2 b.local\nbad line here\n",
+		"/dns/lock/694d9a1c2b3e4f01": "",
+	}}
+	results := Data(context.Background(), kv, "/dns")
+
+	if r := find(t, results, "mode"); !strings.Contains(r.Detail, "perhost (detected") {
+		t.Errorf("mode = %+v", r)
+	}
+	if r := find(t, results, "key"); r.Status != StatusPass || r.Detail != "2 per-host key(s)" {
+		t.Errorf("key = %+v", r)
+	}
+	data := find(t, results, "data")
+	if data.Status != StatusFail || len(data.Lines) != 1 || !strings.HasPrefix(data.Lines[0], "b.local.: line 2:") {
+		t.Errorf("data = %+v", data)
+	}
+}
+
+func TestData_Stack(t *testing.T) {
+	kv := &fakeKV{data: map[string]string{
+		"/etcdhosts": "10.0.0.1 a.local\n2001:db8::1 a.local\n10.0.0.2 b.local\n",
+	}}
+	r := find(t, Data(context.Background(), kv, "/etcdhosts"), "stack")
+	if r.Status != StatusWarn || len(r.Lines) != 1 || r.Lines[0] != "b.local. has no IPv6 (AAAA) records" {
+		t.Errorf("stack = %+v", r)
+	}
+
+	kv.data["/etcdhosts"] = "10.0.0.1 a.local\n10.0.0.2 b.local\n"
+	r = find(t, Data(context.Background(), kv, "/etcdhosts"), "stack")
+	if r.Status != StatusPass || r.Detail != "0 dual-stack, 2 IPv4-only, 0 IPv6-only hostname(s)" {
+		t.Errorf("stack of IPv4-only data = %+v, want pass", r)
+	}
+}
+
+func TestData_Missing(t *testing.T) {
+	results := Data(context.Background(), &fakeKV{}, "/etcdhosts")
+	if len(results) != 1 || results[0].Status != StatusFail || results[0].Check != "key" {
+		t.Errorf("Data() = %v, want a failed key check", results)
+	}
+}
+
+func TestCertExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	cert := func(notAfter time.Time) *x509.Certificate {
+		return &x509.Certificate{
+			Subject:   pkix.Name{CommonName: "etcd"},
+			NotBefore: now.AddDate(-1, 0, 0),
+			NotAfter:  notAfter,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		want     Status
+		detail   string
+	}{
+		{"valid", now.AddDate(1, 0, 0), StatusPass, "valid until 2027-10-19"},
+		{"expiring", now.AddDate(0, 0, 10), StatusWarn, "expires in 10 days"},
+		{"expired", now.AddDate(0, 0, -1), StatusFail, "expired on 2026-10-18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := certExpiry("tls", "https://etcd:2379", cert(tt.notAfter), now)
+			if r.Status != tt.want || !strings.Contains(r.Detail, tt.detail) {
+				t.Errorf("certExpiry() = %+v, want %s %q", r, tt.want, tt.detail)
+			}
+		})
+	}
+}
+
+func TestDialAddress(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		network  string
+		addr     string
+		secure   bool
+	}{
+		{"http://10.0.0.1:2379", "tcp", "10.0.0.1:2379", false},
+		{"https://etcd.example.com", "tcp", "etcd.example.com:2379", true},
+		{"localhost:2379", "tcp", "localhost:2379", false},
+		{"unixs:///run/etcd.sock", "unix", "/run/etcd.sock", true},
+	}
+	for _, tt := range tests {
+		network, addr, secure, err := dialAddress(tt.endpoint)
+		if err != nil {
+			t.Errorf("dialAddress(%q) error = %v", tt.endpoint, err)
+			continue
+		}
+		if network != tt.network || addr != tt.addr || secure != tt.secure {
+			t.Errorf("dialAddress(%q) = %s %s %v, want %s %s %v", tt.endpoint, network, addr, secure, tt.network, tt.addr, tt.secure)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 3 << 20: "3.0 MiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package doctor
+
+import (
+	"strings"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Results are the results of a diagnosis.
+type Results []Result
+
+// Table implements output.Tabular.
+func (rs Results) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "STATUS"}, {Name: "CHECK"}, {Name: "TARGET"}, {Name: "DETAIL"},
+		{Name: "LINES", Wide: true},
+	}}
+	for _, r := range rs {
+		t.Rows = append(t.Rows, []string{
+			strings.ToUpper(string(r.Status)),
+			r.Check,
+			r.Target,
+			r.Detail,
+			output.OrDash(strings.Join(r.Lines, "; ")),
+		})
+	}
+	return t
+}
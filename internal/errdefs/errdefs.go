@@ -0,0 +1,184 @@
+// Package errdefs classifies errors into kinds with stable exit codes.
+package errdefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/retry"
+)
+
+// Kind is the class of an error.
+type Kind string
+
+// Error kinds. The exit code of each kind is part of the command line
+// interface and must not change.
+const (
+	General     Kind = "error"
+	Usage       Kind = "usage"
+	Config      Kind = "config"
+	Connection  Kind = "connection"
+	Auth        Kind = "auth"
+	Validation  Kind = "validation"
+	Conflict    Kind = "conflict"
+	NotFound    Kind = "not_found"
+	Timeout     Kind = "timeout"
+	Interrupted Kind = "interrupted"
+)
+
+var exitCodes = map[Kind]int{
+	General:     1,
+	Usage:       2,
+	Config:      3,
+	Connection:  4,
+	Auth:        5,
+	Validation:  6,
+	Conflict:    7,
+	NotFound:    8,
+	Timeout:     9,
+	Interrupted: 130,
+}
+
+// Code returns the exit code of k.
+func (k Kind) Code() int {
+	if code, ok := exitCodes[k]; ok {
+		return code
+	}
+	return 1
+}
+
+// ErrOutcomeUnknown marks a write that was interrupted or timed out after
+// it was sent to etcd: it may or may not have been applied. It is wrapped
+// together with the cause, which keeps its kind.
+var ErrOutcomeUnknown = errors.New("write outcome unknown")
+
+// Error is an error of a known kind. Details, e.g. the invalid lines of
+// a hosts file, are reported after the message.
+type Error struct {
+	Kind    Kind
+	Err     error
+	Details []string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error of kind k with message msg.
+func New(k Kind, msg string) error {
+	return &Error{Kind: k, Err: errors.New(msg)}
+}
+
+// Errorf returns an error of kind k formatted like fmt.Errorf.
+func Errorf(k Kind, format string, args ...any) error {
+	return &Error{Kind: k, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap marks err as kind k. It returns nil if err is nil and keeps the
+// kind of an error that already has one.
+func Wrap(k Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Kind: k, Err: err}
+}
+
+// Default marks err as kind k unless a more specific kind is known for it.
+func Default(k Kind, err error) error {
+	if err == nil || KindOf(err) != General {
+		return err
+	}
+	return &Error{Kind: k, Err: err}
+}
+
+// authErrors are etcd errors caused by missing or wrong credentials.
+var authErrors = []error{
+	rpctypes.ErrAuthFailed,
+	rpctypes.ErrAuthNotEnabled,
+	rpctypes.ErrInvalidAuthToken,
+	rpctypes.ErrInvalidAuthMgmt,
+	rpctypes.ErrPermissionDenied,
+	rpctypes.ErrUserEmpty,
+	rpctypes.ErrUserNotFound,
+}
+
+// notFoundErrors are etcd errors for revisions that do not exist.
+var notFoundErrors = []error{
+	rpctypes.ErrCompacted,
+	rpctypes.ErrFutureRev,
+	os.ErrNotExist,
+}
+
+// KindOf returns the kind of err: the kind it was marked with, or one
+// derived from etcd, gRPC and context errors.
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, context.Canceled) {
+		return Interrupted
+	}
+	for _, target := range authErrors {
+		if errors.Is(err, target) {
+			return Auth
+		}
+	}
+	for _, target := range notFoundErrors {
+		if errors.Is(err, target) {
+			return NotFound
+		}
+	}
+	if retry.Retryable(err) || errors.Is(err, context.DeadlineExceeded) {
+		return Connection
+	}
+
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return Auth
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return Connection
+	}
+	return General
+}
+
+// Details returns the details of err, if any.
+func Details(err error) []string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Details
+	}
+	return nil
+}
+
+// Report is the machine readable form of an error.
+type Report struct {
+	Error   string   `json:"error"`
+	Kind    Kind     `json:"kind"`
+	Code    int      `json:"code"`
+	Details []string `json:"details,omitempty"`
+}
+
+// NewReport returns the report of err.
+func NewReport(err error) Report {
+	k := KindOf(err)
+	return Report{Error: err.Error(), Kind: k, Code: k.Code(), Details: Details(err)}
+}
@@ -0,0 +1,103 @@
+package errdefs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Kind
+	}{
+		{nil, ""},
+		{errors.New("boom"), General},
+		{New(Validation, "invalid"), Validation},
+		{fmt.Errorf("edit: %w", Errorf(Conflict, "version conflict: current=%d", 3)), Conflict},
+		{fmt.Errorf("failed to get meta: %w", context.DeadlineExceeded), Connection},
+		{fmt.Errorf("read: %w", rpctypes.ErrLeaderChanged), Connection},
+		{status.Error(codes.Unavailable, "connection refused"), Connection},
+		{fmt.Errorf("failed to create etcd client: %w", rpctypes.ErrAuthFailed), Auth},
+		{rpctypes.ErrPermissionDenied, Auth},
+		{status.Error(codes.Unauthenticated, "token expired"), Auth},
+		{fmt.Errorf("failed to get key: %w", rpctypes.ErrCompacted), NotFound},
+		{rpctypes.ErrFutureRev, NotFound},
+		{&fs.PathError{Op: "open", Path: "hosts.txt", Err: fs.ErrNotExist}, NotFound},
+		{context.Canceled, Interrupted},
+		{fmt.Errorf("read: %w", context.Cause(canceledWithCause())), Interrupted},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.err); got != tt.want {
+			t.Errorf("KindOf(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// canceledWithCause returns a context canceled like signal.NotifyContext
+// does on a signal.
+func canceledWithCause() context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(fmt.Errorf("interrupt signal received: %w", context.Canceled))
+	return ctx
+}
+
+func TestCode(t *testing.T) {
+	want := map[Kind]int{
+		General: 1, Usage: 2, Config: 3, Connection: 4, Auth: 5,
+		Validation: 6, Conflict: 7, NotFound: 8, Timeout: 9, Interrupted: 130,
+		Kind("unknown"): 1,
+	}
+	for k, code := range want {
+		if got := k.Code(); got != code {
+			t.Errorf("%q.Code() = %d, want %d", k, got, code)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(Config, nil) != nil || Default(Config, nil) != nil {
+		t.Error("wrapping nil should return nil")
+	}
+
+	base := errors.New("bad endpoint")
+	err := Wrap(Config, base)
+	if KindOf(err) != Config || !errors.Is(err, base) || err.Error() != "bad endpoint" {
+		t.Errorf("Wrap() = %v (%s)", err, KindOf(err))
+	}
+	if got := KindOf(Wrap(Usage, err)); got != Config {
+		t.Errorf("Wrap() replaced kind %s with %s", Config, got)
+	}
+
+	if got := KindOf(Default(Config, rpctypes.ErrAuthFailed)); got != Auth {
+		t.Errorf("Default() on an auth error = %s, want %s", got, Auth)
+	}
+	if got := KindOf(Default(Config, base)); got != Config {
+		t.Errorf("Default() on a general error = %s, want %s", got, Config)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	err := &Error{
+		Kind:    Validation,
+		Err:     errors.New("found 1 invalid record(s)"),
+		Details: []string{`line 2: invalid IP address: bad: "bad line"`},
+	}
+	data, _ := json.Marshal(NewReport(fmt.Errorf("edit: %w", err)))
+	want := `{"error":"edit: found 1 invalid record(s)","kind":"validation","code":6,"details":["line 2: invalid IP address: bad: \"bad line\""]}`
+	if string(data) != want {
+		t.Errorf("report = %s, want %s", data, want)
+	}
+
+	data, _ = json.Marshal(NewReport(errors.New("aborted")))
+	if want := `{"error":"aborted","kind":"error","code":1}`; string(data) != want {
+		t.Errorf("report = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,218 @@
+// Package healthcheck runs the health checks of hosts records.
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Status is the outcome of a health check.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+	// StatusNone is the status of records without a health check, which
+	// always count as healthy.
+	StatusNone Status = "none"
+)
+
+// Options configure a run of health checks.
+type Options struct {
+	// Workers is the number of checks run at the same time.
+	Workers int
+	// Timeout limits each check.
+	Timeout time.Duration
+}
+
+// Result is the outcome of the health check of one record.
+type Result struct {
+	Hostname string `json:"hostname" yaml:"hostname"`
+	IP       net.IP `json:"ip" yaml:"ip"`
+	Check    string `json:"check" yaml:"check"`
+	Status   Status `json:"status" yaml:"status"`
+	// LatencyMS is the duration of the check in milliseconds.
+	LatencyMS float64 `json:"latency_ms,omitempty" yaml:"latency_ms,omitempty"`
+	Error     string  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Healthy reports whether the record can be used in answers.
+func (r Result) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+// Run checks every record with a pool of opts.Workers workers and
+// returns the results in the order of recs. It stops starting checks
+// when ctx is done; the remaining records are reported down with the
+// error of ctx.
+func Run(ctx context.Context, recs []client.Record, opts Options) []Result {
+	results := make([]Result, len(recs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = Check(ctx, recs[i], opts.Timeout)
+			}
+		}()
+	}
+	for i := range recs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// Check runs the health check of r, limited to timeout.
+func Check(ctx context.Context, r client.Record, timeout time.Duration) Result {
+	res := Result{Hostname: r.Hostname, IP: r.IP, Check: "-", Status: StatusNone}
+	if r.Health == nil {
+		return res
+	}
+	res.Check = Describe(r.Health)
+
+	if err := ctx.Err(); err != nil {
+		res.Status, res.Error = StatusDown, err.Error()
+		return res
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch r.Health.Type {
+	case client.CheckTCP:
+		err = checkTCP(ctx, r.IP, port(r.Health))
+	case client.CheckHTTP, client.CheckHTTPS:
+		err = checkHTTP(ctx, r)
+	case client.CheckICMP:
+		err = checkICMP(ctx, r.IP)
+	default:
+		err = fmt.Errorf("unsupported check type %q", r.Health.Type)
+	}
+	res.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		res.Status, res.Error = StatusDown, err.Error()
+		return res
+	}
+	res.Status = StatusUp
+	return res
+}
+
+// Describe formats a health check, such as http:8080/health.
+func Describe(h *client.Health) string {
+	if h.Type == client.CheckICMP {
+		return "icmp"
+	}
+	return string(h.Type) + ":" + strconv.Itoa(port(h)) + h.Path
+}
+
+// port returns the port of a check, defaulting to 80 for http and 443
+// for https.
+func port(h *client.Health) int {
+	switch {
+	case h.Port != 0:
+		return h.Port
+	case h.Type == client.CheckHTTPS:
+		return 443
+	default:
+		return 80
+	}
+}
+
+func checkTCP(ctx context.Context, ip net.IP, port int) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkHTTP sends a GET request to the record's IP with the hostname as
+// Host header and TLS server name. Any 2xx or 3xx status passes;
+// redirects are not followed and certificates are not verified, since
+// the backend is addressed by IP.
+func checkHTTP(ctx context.Context, r client.Record) error {
+	host := strings.TrimSuffix(r.Hostname, ".")
+	u := url.URL{
+		Scheme: string(r.Health.Type),
+		Host:   net.JoinHostPort(r.IP.String(), strconv.Itoa(port(r.Health))),
+		Path:   "/" + strings.TrimPrefix(r.Health.Path, "/"),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Host = host
+
+	hc := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{ServerName: host, InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			return uerr.Err
+		}
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return nil
+}
+
+// Summary counts the healthy records of a hostname.
+type Summary struct {
+	Hostname string `json:"hostname" yaml:"hostname"`
+	Healthy  int    `json:"healthy" yaml:"healthy"`
+	Total    int    `json:"total" yaml:"total"`
+}
+
+// Summarize counts the healthy records of each hostname, in the order
+// the hostnames first appear in results.
+func Summarize(results []Result) []Summary {
+	var out []Summary
+	idx := make(map[string]int)
+	for _, r := range results {
+		name := records.NormalizeHostname(r.Hostname)
+		i, ok := idx[name]
+		if !ok {
+			i = len(out)
+			idx[name] = i
+			out = append(out, Summary{Hostname: name})
+		}
+		out[i].Total++
+		if r.Healthy() {
+			out[i].Healthy++
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+// listenerPort returns the port of a test server address.
+func listenerPort(t *testing.T, addr string) int {
+	t.Helper()
+	_, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(p)
+	return port
+}
+
+// closedPort returns a local port with nothing listening on it.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listenerPort(t, l.Addr().String())
+	_ = l.Close()
+	return port
+}
+
+func TestCheck(t *testing.T) {
+	hosts := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			hosts <- r.Host
+			w.WriteHeader(http.StatusOK)
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	port := listenerPort(t, srv.Listener.Addr().String())
+	closed := closedPort(t)
+
+	ip := net.ParseIP("127.0.0.1")
+	tests := []struct {
+		health *client.Health
+		status Status
+		err    string
+	}{
+		{nil, StatusNone, ""},
+		{&client.Health{Type: client.CheckTCP, Port: port}, StatusUp, ""},
+		{&client.Health{Type: client.CheckTCP, Port: closed}, StatusDown, "refused"},
+		{&client.Health{Type: client.CheckHTTP, Port: port, Path: "/health"}, StatusUp, ""},
+		{&client.Health{Type: client.CheckHTTP, Port: port, Path: "/down"}, StatusDown, "503"},
+		{&client.Health{Type: client.CheckHTTP, Port: port, Path: "/slow"}, StatusDown, "timed out after 100ms"},
+		{&client.Health{Type: "udp", Port: port}, StatusDown, "unsupported"},
+	}
+	for _, tt := range tests {
+		r := client.Record{Hostname: "api.local.", IP: ip, Health: tt.health}
+		res := Check(context.Background(), r, 100*time.Millisecond)
+		if res.Status != tt.status || !strings.Contains(res.Error, tt.err) {
+			t.Errorf("Check(%s) = %s %q, want %s %q", res.Check, res.Status, res.Error, tt.status, tt.err)
+		}
+	}
+	if host := <-hosts; host != "api.local" {
+		t.Errorf("Host header = %q, want api.local", host)
+	}
+}
+
+func TestCheck_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := client.Record{Hostname: "api.local.", IP: net.ParseIP("127.0.0.1"), Health: &client.Health{Type: client.CheckTCP, Port: 1}}
+	if res := Check(ctx, r, time.Second); res.Status != StatusDown || !strings.Contains(res.Error, "canceled") {
+		t.Errorf("Check() = %s %q, want down and canceled", res.Status, res.Error)
+	}
+}
+
+func TestCheck_ICMP(t *testing.T) {
+	r := client.Record{Hostname: "lo.local.", IP: net.ParseIP("127.0.0.1"), Health: &client.Health{Type: client.CheckICMP}}
+	res := Check(context.Background(), r, time.Second)
+	if strings.Contains(res.Error, "not permitted") {
+		t.Skip(res.Error)
+	}
+	if res.Status != StatusUp {
+		t.Errorf("Check(icmp 127.0.0.1) = %s %q, want up", res.Status, res.Error)
+	}
+}
+
+func TestRunAndSummarize(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	up := listenerPort(t, l.Addr().String())
+	down := closedPort(t)
+
+	ip := net.ParseIP("127.0.0.1")
+	recs := []client.Record{
+		{Hostname: "api.local.", IP: ip, Health: &client.Health{Type: client.CheckTCP, Port: up}},
+		{Hostname: "db.local.", IP: ip, Health: &client.Health{Type: client.CheckTCP, Port: down}},
+		{Hostname: "api.local.", IP: net.ParseIP("::1"), Health: &client.Health{Type: client.CheckTCP, Port: down}},
+		{Hostname: "web.local.", IP: ip},
+	}
+	results := Run(context.Background(), recs, Options{Workers: 2, Timeout: time.Second})
+
+	var statuses []string
+	for _, r := range results {
+		statuses = append(statuses, r.Hostname+"="+string(r.Status))
+	}
+	if got := strings.Join(statuses, " "); got != "api.local.=up db.local.=down api.local.=down web.local.=none" {
+		t.Errorf("Run() = %s", got)
+	}
+
+	want := []Summary{{"api.local.", 1, 2}, {"db.local.", 0, 1}, {"web.local.", 1, 1}}
+	got := Summarize(results)
+	if len(got) != len(want) {
+		t.Fatalf("Summarize() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Summarize()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := map[string]*client.Health{
+		"icmp":             {Type: client.CheckICMP},
+		"tcp:22":           {Type: client.CheckTCP, Port: 22},
+		"http:80/health":   {Type: client.CheckHTTP, Path: "/health"},
+		"https:443":        {Type: client.CheckHTTPS},
+		"http:8080/status": {Type: client.CheckHTTP, Port: 8080, Path: "/status"},
+	}
+	for want, h := range tests {
+		if got := Describe(h); got != want {
+			t.Errorf("Describe(%+v) = %q, want %q", h, got, want)
+		}
+	}
+}
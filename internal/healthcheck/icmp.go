@@ -0,0 +1,109 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// icmpSeq numbers the echo requests of this process.
+var icmpSeq atomic.Uint32
+
+// checkICMP sends an echo request to ip and waits for the reply. It uses
+// an unprivileged ICMP socket where the system allows it and a raw
+// socket otherwise, which needs root or CAP_NET_RAW.
+func checkICMP(ctx context.Context, ip net.IP) error {
+	v4 := ip.To4() != nil
+	conn, dst, err := listenICMP(v4, ip)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	var typ icmp.Type = ipv4.ICMPTypeEcho
+	var replyType icmp.Type = ipv4.ICMPTypeEchoReply
+	proto := 1
+	if !v4 {
+		typ, replyType, proto = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, 58
+	}
+	id, seq := os.Getpid()&0xffff, int(icmpSeq.Add(1)&0xffff)
+	msg := icmp.Message{Type: typ, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("dnsctl")}}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteTo(data, dst); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		// Unprivileged sockets rewrite the ID, so only raw sockets
+		// compare it.
+		if !ok || echo.Seq != seq || (isRaw(dst) && echo.ID != id) || !samePeer(peer, ip) {
+			continue
+		}
+		return nil
+	}
+}
+
+// listenICMP opens an ICMP socket for the family of ip and returns the
+// address to send to.
+func listenICMP(v4 bool, ip net.IP) (*icmp.PacketConn, net.Addr, error) {
+	udp, raw := "udp6", "ip6:ipv6-icmp"
+	if v4 {
+		udp, raw = "udp4", "ip4:icmp"
+	}
+	if conn, err := icmp.ListenPacket(udp, ""); err == nil {
+		return conn, &net.UDPAddr{IP: ip}, nil
+	}
+	conn, err := icmp.ListenPacket(raw, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("icmp not permitted (needs root, CAP_NET_RAW or net.ipv4.ping_group_range): %w", err)
+	}
+	return conn, &net.IPAddr{IP: ip}, nil
+}
+
+func isRaw(addr net.Addr) bool {
+	_, ok := addr.(*net.IPAddr)
+	return ok
+}
+
+func samePeer(peer net.Addr, ip net.IP) bool {
+	switch a := peer.(type) {
+	case *net.UDPAddr:
+		return a.IP.Equal(ip)
+	case *net.IPAddr:
+		return a.IP.Equal(ip)
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package healthcheck
+
+import (
+	"fmt"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Results are the outcomes of a check run.
+type Results []Result
+
+// Table implements output.Tabular.
+func (rs Results) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "HOSTNAME"}, {Name: "IP"}, {Name: "CHECK"}, {Name: "STATUS"}, {Name: "LATENCY"}, {Name: "ERROR"},
+	}}
+	for _, r := range rs {
+		latency := "-"
+		if r.Status != StatusNone {
+			latency = fmt.Sprintf("%.1fms", r.LatencyMS)
+		}
+		t.Rows = append(t.Rows, []string{
+			r.Hostname,
+			r.IP.String(),
+			r.Check,
+			string(r.Status),
+			latency,
+			output.OrDash(r.Error),
+		})
+	}
+	return t
+}
@@ -0,0 +1,117 @@
+// Package history builds change summaries from hosts history.
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/audit"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Entry describes a single version of the hosts data.
+type Entry struct {
+	Revision int64     `json:"revision" yaml:"revision"`
+	Version  int64     `json:"version" yaml:"version"`
+	Records  int       `json:"records" yaml:"records"`
+	Modified time.Time `json:"modified,omitzero" yaml:"modified,omitempty"`
+	Latest   bool      `json:"latest,omitempty" yaml:"latest,omitempty"`
+
+	// Domain is set for per-host entries merged into a timeline.
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+
+	// Changes compares this version with the previous one. It is nil for
+	// the oldest available version unless that version created the key.
+	Changes *records.Summary `json:"changes,omitempty" yaml:"changes,omitempty"`
+
+	Author  string `json:"author,omitempty" yaml:"author,omitempty"`
+	Dnsctl  string `json:"dnsctl,omitempty" yaml:"dnsctl,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Build converts history, ordered newest first, into entries with change
+// summaries computed from adjacent versions and annotations matched by
+// revision.
+func Build(versions []*client.Hosts, annotations map[int64]*audit.Entry) []Entry {
+	entries := make([]Entry, 0, len(versions))
+
+	for i, h := range versions {
+		recs := h.Records()
+		e := Entry{
+			Revision: h.ModRevision(),
+			Version:  h.Version(),
+			Records:  len(recs),
+			Modified: h.Modified(),
+			Latest:   i == 0,
+		}
+
+		switch {
+		case i+1 < len(versions):
+			s := records.Summarize(versions[i+1].Records(), recs)
+			e.Changes = &s
+		case h.Version() == 1:
+			s := records.Summarize(nil, recs)
+			e.Changes = &s
+		}
+
+		if a, ok := annotations[e.Revision]; ok {
+			e.Author = a.Author()
+			e.Dnsctl = a.Version
+			e.Message = a.Message
+		}
+
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Filter returns the entries modified within [since, until].
+// A zero bound is not applied. Entries with an unknown modification time
+// are dropped when any bound is set.
+func Filter(entries []Entry, since, until time.Time) []Entry {
+	if since.IsZero() && until.IsZero() {
+		return entries
+	}
+
+	filtered := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.Modified.IsZero() {
+			continue
+		}
+		if !since.IsZero() && e.Modified.Before(since) {
+			continue
+		}
+		if !until.IsZero() && e.Modified.After(until) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+// Merge combines per-domain histories into a single timeline ordered by
+// revision, newest first. Each entry is tagged with its domain and only
+// the newest entry overall is marked latest.
+func Merge(domains map[string][]Entry) []Entry {
+	merged := make([]Entry, 0)
+	for domain, entries := range domains {
+		for _, e := range entries {
+			e.Domain = strings.TrimSuffix(domain, ".")
+			merged = append(merged, e)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Revision != merged[j].Revision {
+			return merged[i].Revision > merged[j].Revision
+		}
+		return merged[i].Domain < merged[j].Domain
+	})
+	for i := range merged {
+		merged[i].Latest = i == 0
+	}
+	return merged
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/audit"
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+func version(t *testing.T, at time.Time, data string) *client.Hosts {
+	t.Helper()
+	h, err := client.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	h.SetModified(at)
+	return h
+}
+
+func TestBuild(t *testing.T) {
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+
+	versions := []*client.Hosts{
+		version(t, t3, "10.0.0.1 web.local\n10.0.0.2 api.local # +etcdhosts weight=5\n10.0.0.4 cache.local\n"),
+		version(t, t2, "10.0.0.1 web.local\n10.0.0.2 api.local\n10.0.0.3 db.local\n"),
+		version(t, t1, "10.0.0.1 web.local\n"),
+	}
+	annotations := map[int64]*audit.Entry{
+		// parsed versions have no revision, so all match revision 0
+		0: {User: "alice", Host: "ops1", Version: "v2.2.0", Message: "add cache"},
+	}
+
+	entries := Build(versions, annotations)
+	if len(entries) != 3 {
+		t.Fatalf("Build() returned %d entries, want 3", len(entries))
+	}
+
+	if !entries[0].Latest || entries[1].Latest {
+		t.Error("only the first entry should be marked latest")
+	}
+	if entries[0].Records != 3 {
+		t.Errorf("Records = %d, want 3", entries[0].Records)
+	}
+	if entries[0].Changes == nil || *entries[0].Changes != (records.Summary{Added: 1, Removed: 1, Changed: 1}) {
+		t.Errorf("Changes[0] = %+v, want +1 -1 ~1", entries[0].Changes)
+	}
+	if entries[1].Changes == nil || *entries[1].Changes != (records.Summary{Added: 2}) {
+		t.Errorf("Changes[1] = %+v, want +2", entries[1].Changes)
+	}
+	// oldest version of unknown origin has no summary
+	if entries[2].Changes != nil {
+		t.Errorf("Changes[2] = %+v, want nil", entries[2].Changes)
+	}
+	if entries[0].Author != "alice@ops1" || entries[0].Dnsctl != "v2.2.0" || entries[0].Message != "add cache" {
+		t.Errorf("annotation not applied: %+v", entries[0])
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Revision: 4, Modified: t1.Add(3 * time.Hour)},
+		{Revision: 3, Modified: t1.Add(2 * time.Hour)},
+		{Revision: 2, Modified: t1.Add(time.Hour)},
+		{Revision: 1},
+	}
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		until    time.Time
+		expected []int64
+	}{
+		{"no bounds", time.Time{}, time.Time{}, []int64{4, 3, 2, 1}},
+		{"since", t1.Add(2 * time.Hour), time.Time{}, []int64{4, 3}},
+		{"until", time.Time{}, t1.Add(2 * time.Hour), []int64{3, 2}},
+		{"both", t1.Add(90 * time.Minute), t1.Add(150 * time.Minute), []int64{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Filter(entries, tt.since, tt.until)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Filter() returned %d entries, want %d", len(result), len(tt.expected))
+			}
+			for i, e := range result {
+				if e.Revision != tt.expected[i] {
+					t.Errorf("Filter()[%d].Revision = %d, want %d", i, e.Revision, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	domains := map[string][]Entry{
+		"web.local.": {{Revision: 30, Latest: true}, {Revision: 10}},
+		"api.local.": {{Revision: 20, Latest: true}, {Revision: 5}},
+		"db.local.":  {{Revision: 40, Latest: true}},
+	}
+
+	merged := Merge(domains)
+	expected := []struct {
+		revision int64
+		domain   string
+	}{
+		{40, "db.local"},
+		{30, "web.local"},
+		{20, "api.local"},
+		{10, "web.local"},
+		{5, "api.local"},
+	}
+
+	if len(merged) != len(expected) {
+		t.Fatalf("Merge() returned %d entries, want %d", len(merged), len(expected))
+	}
+	for i, e := range merged {
+		if e.Revision != expected[i].revision || e.Domain != expected[i].domain {
+			t.Errorf("Merge()[%d] = %d %s, want %d %s", i, e.Revision, e.Domain, expected[i].revision, expected[i].domain)
+		}
+		if e.Latest != (i == 0) {
+			t.Errorf("Merge()[%d].Latest = %v", i, e.Latest)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package history
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Entries are versions of the hosts data.
+type Entries []Entry
+
+// Table implements output.Tabular.
+func (es Entries) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "REVISION"}, {Name: "DOMAIN"}, {Name: "VERSION"}, {Name: "RECORDS"}, {Name: "CHANGES"},
+		{Name: "MODIFIED"}, {Name: "AUTHOR"}, {Name: "MESSAGE"},
+		{Name: "DNSCTL", Wide: true}, {Name: "LATEST", Wide: true},
+	}}
+	for _, e := range es {
+		t.Rows = append(t.Rows, []string{
+			strconv.FormatInt(e.Revision, 10),
+			output.OrDash(strings.TrimSuffix(e.Domain, ".")),
+			strconv.FormatInt(e.Version, 10),
+			strconv.Itoa(e.Records),
+			output.FormatChanges(e.Changes),
+			output.FormatTime(e.Modified),
+			output.OrDash(e.Author),
+			output.OrDash(e.Message),
+			output.OrDash(e.Dnsctl),
+			strconv.FormatBool(e.Latest),
+		})
+	}
+	return t
+}
@@ -3,8 +3,11 @@ package output
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
 )
 
 // FormatRecordAttrs formats record attributes for display.
@@ -37,3 +40,27 @@ func FormatHealthCheck(h *client.Health) string {
 	}
 	return fmt.Sprintf("%s:%d", h.Type, h.Port)
 }
+
+// FormatTime formats a timestamp for tables, using "-" for unknown times.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// OrDash returns s, or "-" if s is empty.
+func OrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// FormatChanges formats a change summary as "+added -removed ~changed".
+func FormatChanges(s *records.Summary) string {
+	if s == nil {
+		return "-"
+	}
+	return fmt.Sprintf("+%d -%d ~%d", s.Added, s.Removed, s.Changed)
+}
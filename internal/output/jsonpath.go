@@ -0,0 +1,428 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a kubectl style JSONPath template such as
+// {range .records[*]}{.ip}{"\n"}{end}. It is evaluated against the JSON
+// form of the data, so names are those of the JSON output.
+//
+// Supported are literal text, quoted strings, {range PATH}...{end} and
+// paths made of .name, ..name (recursive), .*, [n], [-n], [*], [a:b]
+// and ['name'], optionally starting with $.
+type jsonPath struct {
+	nodes []jpNode
+}
+
+// jpNode is literal text, a path or a range over a path.
+type jpNode struct {
+	text    string
+	path    []jpStep
+	isRange bool
+	body    []jpNode
+}
+
+// jpStep is one step of a path.
+type jpStep struct {
+	kind      jpKind
+	name      string
+	index     int
+	start, to *int
+}
+
+type jpKind int
+
+const (
+	jpField jpKind = iota
+	jpRecursive
+	jpWildcard
+	jpIndex
+	jpSlice
+)
+
+// parseJSONPath parses a JSONPath template. A template without braces
+// is taken as a single path.
+func parseJSONPath(tmpl string) (*jsonPath, error) {
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+
+	var stack [][]jpNode
+	var nodes []jpNode
+	for rest := tmpl; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			nodes = append(nodes, jpNode{text: rest})
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, jpNode{text: rest[:open]})
+		}
+
+		end := closingBrace(rest, open)
+		if end < 0 {
+			return nil, fmt.Errorf("invalid jsonpath %q: unclosed {", tmpl)
+		}
+		expr := strings.TrimSpace(rest[open+1 : end])
+		rest = rest[end+1:]
+
+		switch {
+		case expr == "end":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: {end} without {range}", tmpl)
+			}
+			body := nodes
+			nodes = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			nodes[len(nodes)-1].body = body
+		case strings.HasPrefix(expr, "range "):
+			path, err := parsePath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath %q: %w", tmpl, err)
+			}
+			stack = append(stack, append(nodes, jpNode{path: path, isRange: true}))
+			nodes = nil
+		case strings.HasPrefix(expr, `"`):
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath %q: bad string %s", tmpl, expr)
+			}
+			nodes = append(nodes, jpNode{text: text})
+		default:
+			path, err := parsePath(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath %q: %w", tmpl, err)
+			}
+			nodes = append(nodes, jpNode{path: path})
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("invalid jsonpath %q: {range} without {end}", tmpl)
+	}
+	return &jsonPath{nodes: nodes}, nil
+}
+
+// closingBrace returns the index of the brace that closes the one at
+// open, skipping braces in quoted strings and names.
+func closingBrace(s string, open int) int {
+	var quote byte
+	for i := open + 1; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == '\\':
+			i++
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote == 0 && (s[i] == '"' || s[i] == '\''):
+			quote = s[i]
+		case quote == 0 && s[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// parsePath parses a path such as .records[*].ip. The path "." selects
+// the current value and gives an empty, non-nil slice of steps.
+func parsePath(expr string) ([]jpStep, error) {
+	steps := []jpStep{}
+	s := strings.TrimPrefix(expr, "$")
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			name, rest := splitName(s[2:])
+			if name == "" {
+				return nil, fmt.Errorf("missing name after .. in %q", expr)
+			}
+			steps = append(steps, jpStep{kind: jpRecursive, name: name})
+			s = rest
+		case strings.HasPrefix(s, ".*"):
+			steps = append(steps, jpStep{kind: jpWildcard})
+			s = s[2:]
+		case s[0] == '.':
+			name, rest := splitName(s[1:])
+			if name != "" {
+				steps = append(steps, jpStep{kind: jpField, name: name})
+			}
+			s = rest
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", expr)
+			}
+			step, err := parseBracket(s[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w in %q", err, expr)
+			}
+			steps = append(steps, step)
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", s, expr)
+		}
+	}
+	return steps, nil
+}
+
+// splitName splits a field name from the rest of a path.
+func splitName(s string) (string, string) {
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// parseBracket parses the inside of [...].
+func parseBracket(s string) (jpStep, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "*":
+		return jpStep{kind: jpWildcard}, nil
+	case strings.HasPrefix(s, "?"):
+		return jpStep{}, fmt.Errorf("filter expressions like [%s] are not supported", s)
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return jpStep{kind: jpField, name: s[1 : len(s)-1]}, nil
+	case strings.Contains(s, ":"):
+		from, to, _ := strings.Cut(s, ":")
+		step := jpStep{kind: jpSlice}
+		for _, b := range []struct {
+			s string
+			p **int
+		}{{from, &step.start}, {to, &step.to}} {
+			if b.s = strings.TrimSpace(b.s); b.s == "" {
+				continue
+			}
+			n, err := strconv.Atoi(b.s)
+			if err != nil {
+				return jpStep{}, fmt.Errorf("invalid slice [%s]", s)
+			}
+			*b.p = &n
+		}
+		return step, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return jpStep{}, fmt.Errorf("invalid index [%s]", s)
+	}
+	return jpStep{kind: jpIndex, index: n}, nil
+}
+
+// Execute writes the template applied to data.
+func (p *jsonPath) Execute(w io.Writer, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := execNodes(&buf, p.nodes, root); err != nil {
+		return err
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func execNodes(w *bytes.Buffer, nodes []jpNode, cur any) error {
+	for _, n := range nodes {
+		switch {
+		case n.isRange:
+			values, err := evalPath(n.path, cur)
+			if err != nil {
+				return err
+			}
+			for _, v := range rangeItems(values) {
+				if err := execNodes(w, n.body, v); err != nil {
+					return err
+				}
+			}
+		case n.path != nil:
+			values, err := evalPath(n.path, cur)
+			if err != nil {
+				return err
+			}
+			for i, v := range values {
+				if i > 0 {
+					w.WriteByte(' ')
+				}
+				if err := writeValue(w, v); err != nil {
+					return err
+				}
+			}
+		default:
+			w.WriteString(n.text)
+		}
+	}
+	return nil
+}
+
+// rangeItems returns the items to range over: the results of the path,
+// or the elements of a single array result.
+func rangeItems(values []any) []any {
+	if len(values) == 1 {
+		if items, ok := values[0].([]any); ok {
+			return items
+		}
+	}
+	return values
+}
+
+// evalPath returns the values selected by steps from cur. An empty path
+// selects cur itself. A field or index that none of the values has is an
+// error; wildcards, slices and recursive names may select nothing.
+func evalPath(steps []jpStep, cur any) ([]any, error) {
+	values := []any{cur}
+	for i, step := range steps {
+		var next []any
+		for _, v := range values {
+			next = append(next, evalStep(step, v)...)
+		}
+		if len(next) == 0 && len(values) > 0 && (step.kind == jpField || step.kind == jpIndex) {
+			return nil, fmt.Errorf("jsonpath: %s not found", pathString(steps[:i+1]))
+		}
+		values = next
+	}
+	return values, nil
+}
+
+// pathString formats steps as a path for error messages.
+func pathString(steps []jpStep) string {
+	var b strings.Builder
+	for _, step := range steps {
+		switch step.kind {
+		case jpField:
+			b.WriteString("." + step.name)
+		case jpRecursive:
+			b.WriteString(".." + step.name)
+		case jpWildcard:
+			b.WriteString("[*]")
+		case jpIndex:
+			fmt.Fprintf(&b, "[%d]", step.index)
+		case jpSlice:
+			b.WriteString("[")
+			if step.start != nil {
+				b.WriteString(strconv.Itoa(*step.start))
+			}
+			b.WriteString(":")
+			if step.to != nil {
+				b.WriteString(strconv.Itoa(*step.to))
+			}
+			b.WriteString("]")
+		}
+	}
+	return b.String()
+}
+
+func evalStep(step jpStep, v any) []any {
+	switch step.kind {
+	case jpField:
+		if m, ok := v.(map[string]any); ok {
+			if f, ok := m[step.name]; ok {
+				return []any{f}
+			}
+		}
+	case jpRecursive:
+		var found []any
+		walk(v, func(m map[string]any) {
+			if f, ok := m[step.name]; ok {
+				found = append(found, f)
+			}
+		})
+		return found
+	case jpWildcard:
+		switch x := v.(type) {
+		case []any:
+			return x
+		case map[string]any:
+			keys := sortedKeys(x)
+			out := make([]any, len(keys))
+			for i, k := range keys {
+				out[i] = x[k]
+			}
+			return out
+		}
+	case jpIndex:
+		if a, ok := v.([]any); ok {
+			i := step.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return []any{a[i]}
+			}
+		}
+	case jpSlice:
+		if a, ok := v.([]any); ok {
+			from, to := 0, len(a)
+			if step.start != nil {
+				from = clampIndex(*step.start, len(a))
+			}
+			if step.to != nil {
+				to = clampIndex(*step.to, len(a))
+			}
+			if from < to {
+				return a[from:to]
+			}
+		}
+	}
+	return nil
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	return min(max(i, 0), n)
+}
+
+// walk calls fn for every object in v, depth first.
+func walk(v any, fn func(map[string]any)) {
+	switch x := v.(type) {
+	case map[string]any:
+		fn(x)
+		for _, k := range sortedKeys(x) {
+			walk(x[k], fn)
+		}
+	case []any:
+		for _, e := range x {
+			walk(e, fn)
+		}
+	}
+}
+
+// writeValue writes strings and numbers as is and other values as JSON.
+func writeValue(w *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case string:
+		w.WriteString(x)
+	case json.Number:
+		w.WriteString(x.String())
+	case nil:
+	default:
+		data, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	data := map[string]any{
+		"version": 3,
+		"a}b":     "x",
+		"records": []map[string]any{
+			{"hostname": "api.local.", "ip": "10.0.0.1", "weight": 3},
+			{"hostname": "api.local.", "ip": "10.0.0.2", "health": map[string]any{"type": "tcp", "port": 80}},
+			{"hostname": "db.local.", "ip": "10.0.0.3"},
+		},
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{.version}", "3\n"},
+		{"$.version", "3\n"},
+		{"{.records[*].ip}", "10.0.0.1 10.0.0.2 10.0.0.3\n"},
+		{"{.records[0].hostname}", "api.local.\n"},
+		{"{.records[-1].ip}", "10.0.0.3\n"},
+		{"{.records[1:].ip}", "10.0.0.2 10.0.0.3\n"},
+		{"{.records[:-2].ip}", "10.0.0.1\n"},
+		{"{..port}", "80\n"},
+		{"{.records[1].health}", `{"port":80,"type":"tcp"}` + "\n"},
+		{"{.records[1]['health'].type}", "tcp\n"},
+		{"{.records[5:9].ip}", ""},
+		{"{.records[-9:1].ip}", "10.0.0.1\n"},
+		{"{.records[*].weight}", "3\n"},
+		{"{..hostname}", "api.local. api.local. db.local.\n"},
+		{"{.records[*]..type}", "tcp\n"},
+		{"{..missing}", ""},
+		{`{"}"}{.version}{"{"}`, "}3{\n"},
+		{`{['a}b']}`, "x\n"},
+		{`{range .records[*]}{.hostname}{"\t"}{.ip}{"\n"}{end}`, "api.local.\t10.0.0.1\napi.local.\t10.0.0.2\ndb.local.\t10.0.0.3\n"},
+		{`{range .records}[{.}]{end}`, `[{"hostname":"api.local.","ip":"10.0.0.1","weight":3}][{"health":{"port":80,"type":"tcp"},"hostname":"api.local.","ip":"10.0.0.2"}][{"hostname":"db.local.","ip":"10.0.0.3"}]` + "\n"},
+		{"version {.version}", "version 3\n"},
+		{`{range .records[*]}{.hostname}:{range .*}[{.}]{end}{"\n"}{end}`, "api.local.:[api.local.][10.0.0.1][3]\napi.local.:[{\"port\":80,\"type\":\"tcp\"}][api.local.][10.0.0.2]\ndb.local.:[db.local.][10.0.0.3]\n"},
+	}
+	for _, tt := range tests {
+		p, err := parseJSONPath(tt.tmpl)
+		if err != nil {
+			t.Fatalf("parseJSONPath(%s) error = %v", tt.tmpl, err)
+		}
+		var buf bytes.Buffer
+		if err := p.Execute(&buf, data); err != nil {
+			t.Fatalf("Execute(%s) error = %v", tt.tmpl, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("Execute(%s) = %q, want %q", tt.tmpl, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestJSONPath_NotFound(t *testing.T) {
+	data := map[string]any{
+		"records": []map[string]any{{"hostname": "api.local."}},
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{.missing}", "jsonpath: .missing not found"},
+		{"{.records[*].ip}", "jsonpath: .records[*].ip not found"},
+		{"{.records[3]}", "jsonpath: .records[3] not found"},
+		{"{range .records[*]}{.hostname}{range .ips}{end}{end}", "jsonpath: .ips not found"},
+	}
+	for _, tt := range tests {
+		p, err := parseJSONPath(tt.tmpl)
+		if err != nil {
+			t.Fatalf("parseJSONPath(%s) error = %v", tt.tmpl, err)
+		}
+		var buf bytes.Buffer
+		if err := p.Execute(&buf, data); err == nil || err.Error() != tt.want {
+			t.Errorf("Execute(%s) error = %v, want %s", tt.tmpl, err, tt.want)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Execute(%s) wrote %q on error", tt.tmpl, buf.String())
+		}
+	}
+}
+
+func TestParseJSONPath_Errors(t *testing.T) {
+	for _, tmpl := range []string{
+		"{.records",
+		"{end}",
+		"{range .records}{.ip}",
+		`{"unterminated}`,
+		"{.records[x]}",
+		"{.records[1}",
+		"{..}",
+		"{records}",
+		`{.records[?(@.ip=="10.0.0.1")].hostname}`,
+	} {
+		if _, err := parseJSONPath(tmpl); err == nil {
+			t.Errorf("parseJSONPath(%s) should fail", tmpl)
+		}
+	}
+}
@@ -5,8 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 
+	client "github.com/etcdhosts/client-go/v2"
 	"gopkg.in/yaml.v3"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
 )
 
 // Stringer is an interface for types that can be converted to string.
@@ -18,24 +24,188 @@ type Stringer interface {
 type Format string
 
 const (
-	FormatHosts Format = "hosts"
-	FormatJSON  Format = "json"
-	FormatYAML  Format = "yaml"
+	FormatHosts  Format = "hosts"
+	FormatTable  Format = "table"
+	FormatWide   Format = "wide"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatJSON   Format = "json"
+	FormatYAML   Format = "yaml"
+
+	// Templated formats take an argument after "=", such as
+	// go-template={{range .Records}}{{.IP}}{{"\n"}}{{end}}.
+	FormatGoTemplate   Format = "go-template"
+	FormatTemplateFile Format = "template-file"
+	FormatJSONPath     Format = "jsonpath"
 )
 
+// DataFormats are the formats of every command that prints data.
+var DataFormats = []Format{
+	FormatTable, FormatWide, FormatCSV, FormatNDJSON, FormatJSON, FormatYAML,
+	FormatGoTemplate, FormatTemplateFile, FormatJSONPath,
+}
+
+// templated reports whether f takes an argument.
+func templated(f Format) bool {
+	return f == FormatGoTemplate || f == FormatTemplateFile || f == FormatJSONPath
+}
+
+// split returns the name and argument of a format.
+func (f Format) split() (Format, string) {
+	name, arg, _ := strings.Cut(string(f), "=")
+	return Format(name), arg
+}
+
+// FormatList joins formats for flag usage and error messages.
+func FormatList(formats []Format) string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f)
+		if templated(f) {
+			names[i] += "=..."
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatHelp describes each format for command help. Templated formats
+// are shown with the name of their argument.
+var formatHelp = map[Format]struct{ arg, text string }{
+	FormatHosts:        {"", "hosts file format"},
+	FormatTable:        {"", "table format (default)"},
+	FormatWide:         {"", "table with more columns"},
+	FormatCSV:          {"", "CSV with all columns"},
+	FormatNDJSON:       {"", "one JSON object per line"},
+	FormatJSON:         {"", "JSON format"},
+	FormatYAML:         {"", "YAML format"},
+	FormatGoTemplate:   {"TEMPLATE", "Go template applied to the data"},
+	FormatTemplateFile: {"PATH", "Go template read from a file"},
+	FormatJSONPath: {"EXPRESSION", "JSONPath expression over the JSON output, with\n" +
+		".name ..name .* [n] [a:b] [*] ['name'] and\n" +
+		"{range PATH}...{end}; no filter expressions"},
+}
+
+// FormatHelp returns the "Output formats:" section of a command's help
+// for formats. Descriptions in overrides replace the common ones, e.g.
+// to name the columns of the table of a command.
+func FormatHelp(formats []Format, overrides map[Format]string) string {
+	var b strings.Builder
+	b.WriteString("Output formats:\n")
+	for _, f := range formats {
+		help := formatHelp[f]
+		if text, ok := overrides[f]; ok {
+			help.text = text
+		}
+		name := fmt.Sprintf("%-6s", f)
+		if help.arg != "" {
+			name = fmt.Sprintf("%-22s", string(f)+"="+help.arg)
+		}
+		indent := "\n" + strings.Repeat(" ", len(name)+5)
+		fmt.Fprintf(&b, "  %s - %s\n", name, strings.ReplaceAll(help.text, "\n", indent))
+	}
+	return b.String()
+}
+
+// ParseFormat returns s as a format if it is one of formats. Templates
+// are checked here so that mistakes are reported before any request is
+// made; template-file=PATH is read and returned as a go-template.
+func ParseFormat(s string, formats []Format) (Format, error) {
+	name, arg := Format(s).split()
+	if !slices.Contains(formats, name) || (!templated(name) && strings.Contains(s, "=")) {
+		return "", errdefs.Errorf(errdefs.Usage, "unknown output format: %s (expected one of %s)", s, FormatList(formats))
+	}
+	if !templated(name) {
+		return name, nil
+	}
+	if arg == "" {
+		return "", errdefs.Errorf(errdefs.Usage, "output format %s requires an argument: %s=...", name, name)
+	}
+
+	switch name {
+	case FormatTemplateFile:
+		text, err := readTemplateFile(arg)
+		if err != nil {
+			return "", errdefs.Default(errdefs.Usage, err)
+		}
+		if _, err := parseTemplate(arg, text); err != nil {
+			return "", errdefs.Wrap(errdefs.Usage, err)
+		}
+		return FormatGoTemplate + "=" + Format(text), nil
+	case FormatGoTemplate:
+		if _, err := parseTemplate("output", arg); err != nil {
+			return "", errdefs.Wrap(errdefs.Usage, err)
+		}
+	case FormatJSONPath:
+		if _, err := parseJSONPath(arg); err != nil {
+			return "", errdefs.Wrap(errdefs.Usage, err)
+		}
+	}
+	return Format(s), nil
+}
+
 // Print outputs data in the specified format.
 func Print(data any, format Format) error {
-	switch format {
+	switch name, arg := format.split(); name {
+	case FormatGoTemplate:
+		tmpl, err := parseTemplate("output", arg)
+		if err != nil {
+			return err
+		}
+		return executeTemplate(os.Stdout, tmpl, data)
+	case FormatJSONPath:
+		p, err := parseJSONPath(arg)
+		if err != nil {
+			return err
+		}
+		return p.Execute(os.Stdout, data)
 	case FormatJSON:
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(data)
 	case FormatYAML:
 		return yaml.NewEncoder(os.Stdout).Encode(data)
-	default:
-		if s, ok := data.(Stringer); ok {
-			fmt.Print(s.String())
+	case FormatNDJSON:
+		return printNDJSON(data)
+	case FormatTable, FormatWide, FormatCSV:
+		t, ok := tableOf(data)
+		if !ok {
+			return fmt.Errorf("output format %s is not supported for %T", format, data)
+		}
+		if format == FormatCSV {
+			return WriteCSV(os.Stdout, t)
 		}
+		WriteTable(os.Stdout, t, format == FormatWide)
 		return nil
+	case FormatHosts:
+		s, ok := data.(Stringer)
+		if !ok {
+			return fmt.Errorf("output format %s is not supported for %T", format, data)
+		}
+		fmt.Print(s.String())
+		return nil
+	}
+	return errdefs.Errorf(errdefs.Usage, "unknown output format: %s", format)
+}
+
+// printNDJSON prints each element of a slice, or each record of hosts,
+// as one JSON object per line. Other data is printed as a single line.
+func printNDJSON(data any) error {
+	switch d := data.(type) {
+	case *client.Hosts:
+		data = d.Records()
+	case *RecordList:
+		data = d.Records
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return enc.Encode(data)
+	}
+	for i := range v.Len() {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
 	}
+	return nil
 }
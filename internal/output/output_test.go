@@ -3,9 +3,15 @@ package output
 import (
 	"bytes"
 	"io"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
 )
 
 type mockStringer struct {
@@ -96,3 +102,128 @@ func TestFormat_String(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("csv", DataFormats); err != nil || f != FormatCSV {
+		t.Errorf("ParseFormat(csv) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("hosts", DataFormats); err == nil {
+		t.Error("ParseFormat(hosts) should fail when hosts is not allowed")
+	}
+	if _, err := ParseFormat("tabel", DataFormats); err == nil || !strings.Contains(err.Error(), "table, wide, csv, ndjson, json, yaml") {
+		t.Errorf("ParseFormat(tabel) error = %v, want the list of formats", err)
+	}
+}
+
+func TestFormatHelp(t *testing.T) {
+	help := FormatHelp([]Format{FormatTable, FormatWide, FormatJSONPath}, map[Format]string{FormatWide: "table with the TTL"})
+	want := `Output formats:
+  table  - table format (default)
+  wide   - table with the TTL
+  jsonpath=EXPRESSION    - JSONPath expression over the JSON output, with
+                           .name ..name .* [n] [a:b] [*] ['name'] and
+                           {range PATH}...{end}; no filter expressions
+`
+	if help != want {
+		t.Errorf("FormatHelp() =\n%s\nwant\n%s", help, want)
+	}
+}
+
+func TestPrint_UnknownFormat(t *testing.T) {
+	data := mockStringer{value: "192.168.1.1 test.local\n"}
+
+	var err error
+	output := captureStdout(func() {
+		err = Print(data, Format("hots"))
+	})
+	if err == nil || output != "" {
+		t.Errorf("Print(hots) = %q, %v, want an error and no output", output, err)
+	}
+}
+
+func TestPrint_Tabular(t *testing.T) {
+	hosts := client.NewHosts()
+	_ = hosts.Add(client.Record{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1"), Weight: 3, TTL: 60})
+	_ = hosts.Add(client.Record{Hostname: "api.local.", IP: net.ParseIP("10.0.0.2"), Health: &client.Health{Type: client.CheckTCP, Port: 80}})
+
+	table := captureStdout(func() { _ = Print(hosts, FormatTable) })
+	want := `HOSTNAME    IP        WEIGHT  TTL  HEALTH
+----------  --------  ------  ---  ------
+api.local.  10.0.0.1  3       60   -
+api.local.  10.0.0.2  1       -    tcp:80
+`
+	if table != want {
+		t.Errorf("Print(table) =\n%s\nwant\n%s", table, want)
+	}
+
+	wide := captureStdout(func() { _ = Print(hosts, FormatWide) })
+	if !strings.Contains(wide, "FAMILY  SHARE") || !strings.Contains(wide, "v4      75.0%") {
+		t.Errorf("Print(wide) =\n%s", wide)
+	}
+
+	csv := captureStdout(func() { _ = Print(hosts, FormatCSV) })
+	if !strings.HasPrefix(csv, "hostname,ip,weight,ttl,health,family,share\napi.local.,10.0.0.1,3,60,-,v4,75.0%\n") {
+		t.Errorf("Print(csv) =\n%s", csv)
+	}
+
+	ndjson := captureStdout(func() { _ = Print(hosts, FormatNDJSON) })
+	if lines := strings.Split(strings.TrimSpace(ndjson), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"hostname":"api.local.","ip":"10.0.0.1"`) {
+		t.Errorf("Print(ndjson) =\n%s", ndjson)
+	}
+
+	if err := Print(map[string]int{"count": 1}, FormatTable); err == nil {
+		t.Error("Print(table) of data without a table should fail")
+	}
+}
+
+func TestPrint_Resolution(t *testing.T) {
+	res := records.Resolve(testHosts().Records(), "api.local", false)
+
+	table := captureStdout(func() { _ = Print(res, FormatWide) })
+	want := `HOSTNAME    IP        WEIGHT  SHARE   TTL  HEALTH  FAMILY  MATCH
+----------  --------  ------  ------  ---  ------  ------  -----
+api.local.  10.0.0.1  3       100.0%  -    -       v4      exact
+`
+	if table != want {
+		t.Errorf("Print(wide) =\n%s\nwant\n%s", table, want)
+	}
+
+	js := captureStdout(func() { _ = Print(res, FormatJSON) })
+	if !strings.Contains(js, `"match": "exact"`) || !strings.Contains(js, `"share": 1`) {
+		t.Errorf("Print(json) =\n%s", js)
+	}
+}
+
+type tabular []string
+
+func (t tabular) Table() Table {
+	table := Table{Columns: []Column{{Name: "NAME"}, {Name: "LENGTH", Wide: true}}}
+	for _, s := range t {
+		table.Rows = append(table.Rows, []string{s, strconv.Itoa(len(s))})
+	}
+	return table
+}
+
+func TestPrint_TabularInterface(t *testing.T) {
+	data := tabular{"api", "db"}
+
+	table := captureStdout(func() { _ = Print(data, FormatTable) })
+	want := `NAME
+----
+api
+db
+`
+	if table != want {
+		t.Errorf("Print(table) =\n%s\nwant\n%s", table, want)
+	}
+
+	csv := captureStdout(func() { _ = Print(data, FormatCSV) })
+	if want := "name,length\napi,3\ndb,2\n"; csv != want {
+		t.Errorf("Print(csv) = %q, want %q", csv, want)
+	}
+
+	js := captureStdout(func() { _ = Print(data, FormatJSON) })
+	if want := "[\n  \"api\",\n  \"db\"\n]\n"; js != want {
+		t.Errorf("Print(json) = %q, want %q", js, want)
+	}
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// RecordList is a selection of the records of hosts in a given order.
+// Every format prints it like the hosts it was taken from.
+type RecordList struct {
+	Version     int64
+	ModRevision int64
+	Modified    time.Time
+	Records     []client.Record
+
+	// all are the records of hosts, for the weight shares.
+	all []client.Record
+}
+
+// NewRecordList returns recs, taken from h, with the version of h.
+func NewRecordList(h *client.Hosts, recs []client.Record) *RecordList {
+	return &RecordList{
+		Version:     h.Version(),
+		ModRevision: h.ModRevision(),
+		Modified:    h.Modified(),
+		Records:     recs,
+		all:         h.Records(),
+	}
+}
+
+// recordListJSON has the fields and tags of the JSON form of client.Hosts.
+type recordListJSON struct {
+	Version     int64           `json:"version"`
+	ModRevision int64           `json:"mod_revision"`
+	Modified    string          `json:"modified,omitempty"`
+	Records     []client.Record `json:"records"`
+}
+
+func (l *RecordList) view() recordListJSON {
+	v := recordListJSON{Version: l.Version, ModRevision: l.ModRevision, Records: l.Records}
+	if v.Records == nil {
+		v.Records = []client.Record{}
+	}
+	if !l.Modified.IsZero() {
+		v.Modified = l.Modified.UTC().Format(time.RFC3339)
+	}
+	return v
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l *RecordList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.view())
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l *RecordList) MarshalYAML() (any, error) {
+	return l.view(), nil
+}
+
+// String returns the records in hosts file format.
+func (l *RecordList) String() string {
+	var b strings.Builder
+	if !l.Modified.IsZero() {
+		b.WriteString("# +etcdhosts-meta modified=" + l.Modified.UTC().Format(time.RFC3339) + "\n")
+	}
+	for _, r := range l.Records {
+		b.WriteString(FormatHostsLine(r) + "\n")
+	}
+	return b.String()
+}
+
+// FormatHostsLine formats a record as a hosts file line, the same way as
+// client-go writes it.
+func FormatHostsLine(r client.Record) string {
+	line := fmt.Sprintf("%-32s%s", r.IP, r.Hostname)
+	if !r.Extended && r.Weight <= 1 && r.TTL == 0 && r.Health == nil {
+		return line
+	}
+
+	var attrs []string
+	if r.Extended || r.Weight > 1 {
+		attrs = append(attrs, fmt.Sprintf("weight=%d", r.Weight))
+	}
+	if r.TTL > 0 {
+		attrs = append(attrs, fmt.Sprintf("ttl=%d", r.TTL))
+	}
+	if r.Health != nil {
+		attrs = append(attrs, "hc="+FormatHealthCheck(r.Health))
+	}
+	return line + " # +etcdhosts " + strings.Join(attrs, " ")
+}
+
+// recordListTable is RecordsTable with the shares of all records of the
+// hosts, so that filtering does not change them.
+func recordListTable(l *RecordList) Table {
+	return recordsTable(l.Records, records.Shares(l.all))
+}
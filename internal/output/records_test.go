@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/etcdhosts/client-go/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRecordList_SameAsHosts(t *testing.T) {
+	hosts, err := client.Parse([]byte(`# +etcdhosts-meta modified=2026-10-01T10:00:00Z
+10.0.0.1 web.local
+10.0.0.2 api.local # +etcdhosts weight=3 ttl=60
+10.0.0.3 api.local # +etcdhosts weight=1 hc=http:8080/health
+2001:db8::1 api.local # +etcdhosts
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := NewRecordList(hosts, hosts.Records())
+
+	if list.String() != hosts.String() {
+		t.Errorf("String() =\n%s\nwant\n%s", list.String(), hosts.String())
+	}
+	listJSON, _ := json.Marshal(list)
+	hostsJSON, _ := json.Marshal(hosts)
+	if string(listJSON) != string(hostsJSON) {
+		t.Errorf("json = %s, want %s", listJSON, hostsJSON)
+	}
+	listYAML, _ := yaml.Marshal(list)
+	hostsYAML, _ := yaml.Marshal(hosts)
+	if string(listYAML) != string(hostsYAML) {
+		t.Errorf("yaml =\n%s\nwant\n%s", listYAML, hostsYAML)
+	}
+}
+
+func TestRecordList_Selection(t *testing.T) {
+	hosts := testHosts()
+	_ = hosts.Add(client.Record{Hostname: "api.local.", IP: net.ParseIP("10.0.0.2")})
+	hosts.SetModified(time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC))
+	list := NewRecordList(hosts, hosts.Records()[:1])
+
+	if want := "# +etcdhosts-meta modified=2026-10-01T10:00:00Z\n10.0.0.1                        api.local. # +etcdhosts weight=3\n"; list.String() != want {
+		t.Errorf("String() = %q, want %q", list.String(), want)
+	}
+
+	wide := captureStdout(func() { _ = Print(list, FormatWide) })
+	if !strings.Contains(wide, "75.0%") {
+		t.Errorf("Print(wide) should keep the shares of all records:\n%s", wide)
+	}
+
+	empty, _ := json.Marshal(NewRecordList(hosts, nil))
+	if !strings.Contains(string(empty), `"records":[]`) {
+		t.Errorf("json = %s, want an empty list of records", empty)
+	}
+}
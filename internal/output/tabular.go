@@ -0,0 +1,181 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Table is data in rows and columns for the table, wide and csv formats.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+}
+
+// Tabular is data with a tabular form. The results of the commands
+// implement it so that Print can show them in the table, wide and csv
+// formats.
+type Tabular interface {
+	Table() Table
+}
+
+// Column is a table column. Wide columns are only shown by the wide and
+// csv formats.
+type Column struct {
+	Name string
+	Wide bool
+}
+
+// WriteTable writes t as aligned columns with a dashed separator under
+// the header, leaving out the wide columns unless wide is set.
+func WriteTable(w io.Writer, t Table, wide bool) {
+	var keep []int
+	for i, c := range t.Columns {
+		if wide || !c.Wide {
+			keep = append(keep, i)
+		}
+	}
+
+	widths := make([]int, len(keep))
+	for j, i := range keep {
+		widths[j] = utf8.RuneCountInString(t.Columns[i].Name)
+		for _, row := range t.Rows {
+			widths[j] = max(widths[j], utf8.RuneCountInString(row[i]))
+		}
+	}
+
+	writeRow := func(cell func(i int) string) {
+		cells := make([]string, len(keep))
+		for j, i := range keep {
+			cells[j] = cell(i)
+			if j < len(keep)-1 {
+				cells[j] = fmt.Sprintf("%-*s", widths[j], cells[j])
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+
+	writeRow(func(i int) string { return t.Columns[i].Name })
+	dashes := make([]string, len(keep))
+	for j := range keep {
+		dashes[j] = strings.Repeat("-", widths[j])
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(dashes, "  "))
+	for _, row := range t.Rows {
+		writeRow(func(i int) string { return row[i] })
+	}
+}
+
+// WriteCSV writes all columns of t as CSV with a header of lowercase
+// column names.
+func WriteCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = strings.ReplaceAll(strings.ToLower(c.Name), " ", "_")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// tableOf returns the tabular form of data printed by the commands.
+func tableOf(data any) (Table, bool) {
+	switch d := data.(type) {
+	case *client.Hosts:
+		return RecordsTable(d.Records()), true
+	case *RecordList:
+		return recordListTable(d), true
+	case []client.Record:
+		return RecordsTable(d), true
+	case records.Resolution:
+		return resolutionTable(d), true
+	case Tabular:
+		return d.Table(), true
+	case []string:
+		t := Table{Columns: []Column{{Name: "NAME"}}}
+		for _, s := range d {
+			t.Rows = append(t.Rows, []string{s})
+		}
+		return t, true
+	}
+	return Table{}, false
+}
+
+// RecordsTable returns records with their weight, TTL and health check.
+// The wide columns add the address family and the share of the answers
+// for the hostname and family that each record gets by weight.
+func RecordsTable(recs []client.Record) Table {
+	return recordsTable(recs, records.Shares(recs))
+}
+
+func recordsTable(recs []client.Record, shares map[string]float64) Table {
+	t := Table{Columns: []Column{
+		{Name: "HOSTNAME"}, {Name: "IP"}, {Name: "WEIGHT"}, {Name: "TTL"}, {Name: "HEALTH"},
+		{Name: "FAMILY", Wide: true}, {Name: "SHARE", Wide: true},
+	}}
+	for _, r := range recs {
+		t.Rows = append(t.Rows, []string{
+			r.Hostname,
+			r.IP.String(),
+			strconv.Itoa(records.Weight(r)),
+			ttlCell(r),
+			healthCell(r),
+			records.Family(r),
+			FormatShare(shares[records.Key(r)]),
+		})
+	}
+	return t
+}
+
+func ttlCell(r client.Record) string {
+	if r.TTL == 0 {
+		return "-"
+	}
+	return strconv.FormatUint(uint64(r.TTL), 10)
+}
+
+func healthCell(r client.Record) string {
+	if r.Health == nil {
+		return "-"
+	}
+	return FormatHealthCheck(r.Health)
+}
+
+// FormatShare formats a share of the answers as a percentage.
+func FormatShare(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
+
+// resolutionTable returns the answers of a resolution with their share
+// of the traffic.
+func resolutionTable(res records.Resolution) Table {
+	t := Table{Columns: []Column{
+		{Name: "HOSTNAME"}, {Name: "IP"}, {Name: "WEIGHT"}, {Name: "SHARE"}, {Name: "TTL"}, {Name: "HEALTH"},
+		{Name: "FAMILY", Wide: true}, {Name: "MATCH", Wide: true},
+	}}
+	for _, a := range res.Answers {
+		t.Rows = append(t.Rows, []string{
+			a.Hostname,
+			a.IP.String(),
+			strconv.Itoa(records.Weight(a.Record)),
+			FormatShare(a.Share),
+			ttlCell(a.Record),
+			healthCell(a.Record),
+			records.Family(a.Record),
+			res.Match,
+		})
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// templateFuncs are the helper functions available in go-template output.
+var templateFuncs = template.FuncMap{
+	"join":    join,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trimdot": func(s any) string { return strings.TrimSuffix(fmt.Sprint(s), ".") },
+	"fqdn":    func(s any) string { return records.NormalizeHostname(fmt.Sprint(s)) },
+	"json":    toJSON,
+}
+
+// join joins the elements of a list with sep, formatting each with fmt:
+// {{join "," .}} on a list of names.
+func join(sep string, items any) (string, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a list", items)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// parseTemplate parses a go-template with the helper functions.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// readTemplateFile reads the template of a template-file format.
+func readTemplateFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(data), nil
+}
+
+// templateData returns the value that templates are applied to. Hosts
+// are given as a RecordList, so {{range .Records}}{{.IP}}{{end}} works.
+func templateData(data any) any {
+	if h, ok := data.(*client.Hosts); ok {
+		return NewRecordList(h, h.Records())
+	}
+	return data
+}
+
+// executeTemplate writes the template applied to data.
+func executeTemplate(w io.Writer, tmpl *template.Template, data any) error {
+	if err := tmpl.Execute(w, templateData(data)); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	return nil
+}
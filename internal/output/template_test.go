@@ -0,0 +1,91 @@
+package output
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func testHosts() *client.Hosts {
+	hosts := client.NewHosts()
+	_ = hosts.Add(client.Record{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1"), Weight: 3})
+	_ = hosts.Add(client.Record{Hostname: "db.local.", IP: net.ParseIP("2001:db8::1"), TTL: 60})
+	return hosts
+}
+
+func TestPrint_GoTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{`{{range .Records}}{{.IP}}{{"\n"}}{{end}}`, "10.0.0.1\n2001:db8::1\n"},
+		{`{{range .Records}}{{trimdot .Hostname | upper}} {{end}}`, "API.LOCAL DB.LOCAL "},
+		{`{{fqdn "Web.Local"}} {{lower "A"}}`, "web.local. a"},
+		{`{{with index .Records 1}}{{json .}}{{end}}`, `{"hostname":"db.local.","ip":"2001:db8::1","ttl":60}`},
+	}
+	for _, tt := range tests {
+		format, err := ParseFormat("go-template="+tt.tmpl, DataFormats)
+		if err != nil {
+			t.Fatalf("ParseFormat(%s) error = %v", tt.tmpl, err)
+		}
+		got := captureStdout(func() {
+			if err := Print(testHosts(), format); err != nil {
+				t.Errorf("Print(%s) error = %v", tt.tmpl, err)
+			}
+		})
+		if got != tt.want {
+			t.Errorf("Print(%s) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestParseFormat_Templates(t *testing.T) {
+	for _, s := range []string{
+		"go-template",
+		"go-template=",
+		"go-template={{.Records",
+		"go-template={{nope}}",
+		"jsonpath={.records[}",
+		"jsonpath={range .records[*]}",
+		"template-file=" + filepath.Join(t.TempDir(), "missing.tmpl"),
+		"json=x",
+	} {
+		if _, err := ParseFormat(s, DataFormats); err == nil {
+			t.Errorf("ParseFormat(%s) should fail", s)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "ips.tmpl")
+	if err := os.WriteFile(path, []byte(`{{range .Records}}{{.IP}} {{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	format, err := ParseFormat("template-file="+path, DataFormats)
+	if err != nil {
+		t.Fatalf("ParseFormat(template-file) error = %v", err)
+	}
+	if got := captureStdout(func() { _ = Print(testHosts(), format) }); got != "10.0.0.1 2001:db8::1 " {
+		t.Errorf("Print(template-file) = %q", got)
+	}
+
+	if _, err := ParseFormat("jsonpath={.x}", []Format{FormatHosts, FormatJSON}); err == nil || !strings.Contains(err.Error(), "unknown output format") {
+		t.Errorf("ParseFormat(jsonpath) error = %v, want an unknown format", err)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	format, _ := ParseFormat(`go-template={{join "," .}}`, DataFormats)
+	if got := captureStdout(func() { _ = Print([]string{"a.local.", "b.local."}, format) }); got != "a.local.,b.local." {
+		t.Errorf("Print(join) = %q", got)
+	}
+
+	if got, err := join(", ", []string{"a", "b"}); err != nil || got != "a, b" {
+		t.Errorf("join() = %q, %v", got, err)
+	}
+	if _, err := join(",", "a"); err == nil {
+		t.Error("join() of a string should fail")
+	}
+}
@@ -0,0 +1,190 @@
+// Package pool allocates addresses for new records from CIDR pools.
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"slices"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// ErrExhausted is returned when a pool has no free address left.
+var ErrExhausted = errors.New("no free address")
+
+// Pool is a range of addresses to allocate from.
+type Pool struct {
+	Name   string
+	Prefix netip.Prefix
+	// Exclude are ranges in Prefix that are never allocated, such as
+	// gateways or DHCP ranges.
+	Exclude []netip.Prefix
+}
+
+// New parses a pool from a CIDR and exclusions given as IPs or CIDRs.
+func New(name, cidr string, exclude []string) (*Pool, error) {
+	prefix, err := records.ParseNetwork(cidr)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pool{Name: name, Prefix: prefix}
+	for _, s := range exclude {
+		e, err := records.ParseNetwork(s)
+		if err != nil {
+			return nil, fmt.Errorf("exclude: %w", err)
+		}
+		if !prefix.Contains(e.Addr()) || e.Bits() < prefix.Bits() {
+			return nil, fmt.Errorf("exclude %s is outside %s", s, prefix)
+		}
+		p.Exclude = append(p.Exclude, e)
+	}
+	return p, nil
+}
+
+// reserved reports whether addr is the network or broadcast address of
+// an IPv4 pool, or the subnet-router anycast address of an IPv6 pool.
+// Pools of one or two addresses (/31, /32, /127, /128) have none.
+func (p *Pool) reserved(addr netip.Addr) bool {
+	hostBits := addr.BitLen() - p.Prefix.Bits()
+	if hostBits < 2 {
+		return false
+	}
+	if addr == p.Prefix.Addr() {
+		return true
+	}
+	return addr.Is4() && addr == lastAddr(p.Prefix)
+}
+
+// excluded returns the exclusion containing addr.
+func (p *Pool) excluded(addr netip.Addr) (netip.Prefix, bool) {
+	for _, e := range p.Exclude {
+		if e.Contains(addr) {
+			return e, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// Allocatable reports whether addr may be allocated from the pool.
+func (p *Pool) Allocatable(addr netip.Addr) bool {
+	if !p.Prefix.Contains(addr) || p.reserved(addr) {
+		return false
+	}
+	_, ok := p.excluded(addr)
+	return !ok
+}
+
+// used returns the allocatable addresses of recs.
+func (p *Pool) used(recs []client.Record) map[netip.Addr]bool {
+	used := make(map[netip.Addr]bool)
+	for _, r := range recs {
+		if addr := records.Addr(r.IP); p.Allocatable(addr) {
+			used[addr] = true
+		}
+	}
+	return used
+}
+
+// Next returns the lowest allocatable address that no record uses.
+func (p *Pool) Next(recs []client.Record) (netip.Addr, error) {
+	used := p.used(recs)
+	for addr := p.Prefix.Addr(); addr.IsValid() && p.Prefix.Contains(addr); {
+		if e, ok := p.excluded(addr); ok {
+			addr = lastAddr(e).Next()
+			continue
+		}
+		if !p.reserved(addr) && !used[addr] {
+			return addr, nil
+		}
+		addr = addr.Next()
+	}
+	return netip.Addr{}, fmt.Errorf("pool %s (%s): %w", p.Name, p.Prefix, ErrExhausted)
+}
+
+// Size returns the number of allocatable addresses, saturating at
+// math.MaxUint64 for pools of 2^64 addresses or more.
+func (p *Pool) Size() uint64 {
+	size := count(p.Prefix)
+	if size == math.MaxUint64 {
+		return size
+	}
+
+	// Exclusions are prefixes of the pool, so they are either nested or
+	// disjoint; nested ones are only counted once.
+	excl := slices.Clone(p.Exclude)
+	slices.SortFunc(excl, func(a, b netip.Prefix) int { return a.Bits() - b.Bits() })
+	var outer []netip.Prefix
+	for _, e := range excl {
+		if !slices.ContainsFunc(outer, func(o netip.Prefix) bool { return o.Contains(e.Addr()) }) {
+			outer = append(outer, e)
+		}
+	}
+	for _, e := range outer {
+		size -= count(e)
+	}
+	for _, addr := range []netip.Addr{p.Prefix.Addr(), lastAddr(p.Prefix)} {
+		if _, ok := p.excluded(addr); p.reserved(addr) && !ok {
+			size--
+		}
+	}
+	return size
+}
+
+// count returns the number of addresses in prefix, saturating at
+// math.MaxUint64.
+func count(prefix netip.Prefix) uint64 {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 64 {
+		return math.MaxUint64
+	}
+	return 1 << hostBits
+}
+
+// lastAddr returns the highest address of prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// Usage is the utilization of a pool.
+type Usage struct {
+	Pool    string   `json:"pool" yaml:"pool"`
+	CIDR    string   `json:"cidr" yaml:"cidr"`
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	// Size is the number of allocatable addresses.
+	Size uint64 `json:"size" yaml:"size"`
+	Used int    `json:"used" yaml:"used"`
+	// Free saturates like Size.
+	Free uint64 `json:"free" yaml:"free"`
+	// Percent is the share of used addresses, from 0 to 100.
+	Percent float64 `json:"percent" yaml:"percent"`
+	// Next is the address alloc picks next, empty if the pool is full.
+	Next string `json:"next,omitempty" yaml:"next,omitempty"`
+}
+
+// Usage returns the utilization of the pool by recs.
+func (p *Pool) Usage(recs []client.Record) Usage {
+	u := Usage{Pool: p.Name, CIDR: p.Prefix.String(), Size: p.Size(), Used: len(p.used(recs))}
+	for _, e := range p.Exclude {
+		u.Exclude = append(u.Exclude, e.String())
+	}
+	u.Free = u.Size
+	if u.Size < math.MaxUint64 {
+		u.Free -= uint64(u.Used)
+	}
+	if u.Size > 0 {
+		u.Percent = float64(u.Used) / float64(u.Size) * 100
+	}
+	if next, err := p.Next(recs); err == nil {
+		u.Next = next.String()
+	}
+	return u
+}
@@ -0,0 +1,142 @@
+package pool
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/netip"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func recs(ips ...string) []client.Record {
+	var out []client.Record
+	for _, ip := range ips {
+		out = append(out, client.Record{Hostname: "h.example.com.", IP: net.ParseIP(ip)})
+	}
+	return out
+}
+
+func mustNew(t *testing.T, cidr string, exclude ...string) *Pool {
+	t.Helper()
+	p, err := New("lab", cidr, exclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		exclude []string
+		wantErr bool
+	}{
+		{"10.0.0.0/24", []string{"10.0.0.1", "10.0.0.128/25"}, false},
+		{"10.0.0.7/24", nil, false},
+		{"2001:db8::/64", []string{"2001:db8::1"}, false},
+		{"not-a-cidr", nil, true},
+		{"10.0.0.0/24", []string{"10.0.1.1"}, true},
+		{"10.0.0.0/24", []string{"10.0.0.0/16"}, true},
+		{"10.0.0.0/24", []string{"bogus"}, true},
+	}
+	for _, tt := range tests {
+		p, err := New("lab", tt.cidr, tt.exclude)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%s, %v) error = %v, wantErr %v", tt.cidr, tt.exclude, err, tt.wantErr)
+			continue
+		}
+		if err == nil && p.Prefix != p.Prefix.Masked() {
+			t.Errorf("New(%s) prefix = %s, want it masked", tt.cidr, p.Prefix)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	p := mustNew(t, "10.0.0.0/29", "10.0.0.1", "10.0.0.4/31")
+
+	tests := []struct {
+		used []string
+		want string
+	}{
+		{nil, "10.0.0.2"},
+		{[]string{"10.0.0.2"}, "10.0.0.3"},
+		{[]string{"10.0.0.2", "10.0.0.3"}, "10.0.0.6"},
+		{[]string{"::ffff:10.0.0.2", "10.0.0.3", "192.168.0.6"}, "10.0.0.6"},
+	}
+	for _, tt := range tests {
+		got, err := p.Next(recs(tt.used...))
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Next(%v) = %s, %v, want %s", tt.used, got, err, tt.want)
+		}
+	}
+
+	_, err := p.Next(recs("10.0.0.2", "10.0.0.3", "10.0.0.6"))
+	if !errors.Is(err, ErrExhausted) {
+		t.Errorf("Next() of a full pool error = %v, want ErrExhausted", err)
+	}
+
+	// Small pools have no network or broadcast address.
+	if got, err := mustNew(t, "10.0.0.8/31").Next(recs("10.0.0.8")); err != nil || got.String() != "10.0.0.9" {
+		t.Errorf("Next(/31) = %s, %v", got, err)
+	}
+	if got, err := mustNew(t, "2001:db8::/64").Next(nil); err != nil || got.String() != "2001:db8::1" {
+		t.Errorf("Next(v6) = %s, %v", got, err)
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		exclude []string
+		want    uint64
+	}{
+		{"10.0.0.0/24", nil, 254},
+		{"10.0.0.0/24", []string{"10.0.0.1", "10.0.0.200/29"}, 245},
+		{"10.0.0.0/24", []string{"10.0.0.128/25", "10.0.0.130", "10.0.0.128/25"}, 127},
+		{"10.0.0.0/24", []string{"10.0.0.0/30"}, 251},
+		{"10.0.0.0/31", nil, 2},
+		{"10.0.0.1/32", nil, 1},
+		{"2001:db8::/120", nil, 255},
+		{"2001:db8::/64", nil, math.MaxUint64},
+	}
+	for _, tt := range tests {
+		if got := mustNew(t, tt.cidr, tt.exclude...).Size(); got != tt.want {
+			t.Errorf("Size(%s, %v) = %d, want %d", tt.cidr, tt.exclude, got, tt.want)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	p := mustNew(t, "10.0.0.0/29", "10.0.0.1")
+	u := p.Usage(recs("10.0.0.2", "10.0.0.2", "10.0.0.1", "10.0.0.0", "10.0.1.5"))
+	if u.Size != 5 || u.Used != 1 || u.Free != 4 || u.Percent != 20 || u.Next != "10.0.0.3" {
+		t.Errorf("Usage() = %+v", u)
+	}
+	if len(u.Exclude) != 1 || u.Exclude[0] != "10.0.0.1/32" {
+		t.Errorf("Usage() exclude = %v", u.Exclude)
+	}
+
+	u = p.Usage(recs("10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"))
+	if u.Free != 0 || u.Percent != 100 || u.Next != "" {
+		t.Errorf("Usage() of a full pool = %+v", u)
+	}
+}
+
+func TestLastAddr(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"10.0.0.0/24", "10.0.0.255"},
+		{"10.0.0.5/32", "10.0.0.5"},
+		{"10.0.0.0/7", "11.255.255.255"},
+		{"2001:db8::/126", "2001:db8::3"},
+	}
+	for _, tt := range tests {
+		if got := lastAddr(netip.MustParsePrefix(tt.prefix)); got.String() != tt.want {
+			t.Errorf("lastAddr(%s) = %s, want %s", tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package pool
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Usages are the usage of several pools.
+type Usages []Usage
+
+// Table implements output.Tabular.
+func (us Usages) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "POOL"}, {Name: "CIDR"}, {Name: "SIZE"}, {Name: "USED"}, {Name: "FREE"}, {Name: "USAGE"}, {Name: "NEXT"},
+		{Name: "EXCLUDE", Wide: true},
+	}}
+	count := func(n uint64) string {
+		if n == math.MaxUint64 {
+			return "2^64+"
+		}
+		return strconv.FormatUint(n, 10)
+	}
+	for _, u := range us {
+		t.Rows = append(t.Rows, []string{
+			u.Pool,
+			u.CIDR,
+			count(u.Size),
+			strconv.Itoa(u.Used),
+			count(u.Free),
+			fmt.Sprintf("%.1f%%", u.Percent),
+			output.OrDash(u.Next),
+			output.OrDash(strings.Join(u.Exclude, " ")),
+		})
+	}
+	return t
+}
@@ -0,0 +1,311 @@
+// Package ptr derives reverse (PTR) entries from host records.
+package ptr
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Policies choosing the canonical name of an address shared by several
+// hostnames.
+const (
+	// PolicyShortest picks the hostname with the fewest labels, then the
+	// shortest, then the first in alphabetical order.
+	PolicyShortest = "shortest"
+	// PolicyFirst picks the first hostname in alphabetical order.
+	PolicyFirst = "first"
+	// PolicyWeight picks the hostname whose record has the highest
+	// weight, falling back to PolicyShortest on ties.
+	PolicyWeight = "weight"
+	// PolicyAll keeps every hostname, giving one PTR record each.
+	PolicyAll = "all"
+)
+
+// Policies are the policies accepted by Build.
+var Policies = []string{PolicyShortest, PolicyFirst, PolicyWeight, PolicyAll}
+
+// Entry is the reverse entry of one address.
+type Entry struct {
+	IP string `json:"ip" yaml:"ip"`
+	// Name is the in-addr.arpa or ip6.arpa name of the address.
+	Name string `json:"name" yaml:"name"`
+	// Hostnames are the canonical names the address points back to.
+	Hostnames []string `json:"hostnames" yaml:"hostnames"`
+	// Aliases are the other hostnames sharing the address.
+	Aliases []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	// TTL is the lowest TTL of the canonical records, 0 if none is set.
+	TTL uint32 `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// Shared reports whether several hostnames have the address.
+func (e Entry) Shared() bool {
+	return len(e.Hostnames)+len(e.Aliases) > 1
+}
+
+// Key returns the default key for PTR entries of a hosts key. It is a
+// sibling of the hosts key so that per-host data is never touched.
+func Key(hostsKey string) string {
+	return strings.TrimSuffix(hostsKey, "/") + ".ptr"
+}
+
+// ArpaName returns the in-addr.arpa or ip6.arpa name of ip, with a
+// trailing dot.
+func ArpaName(ip net.IP) string {
+	addr := records.Addr(ip)
+	var labels []string
+	if addr.Is4() {
+		b := addr.As4()
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(b[i])))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa."
+	}
+	b := addr.As16()
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, strconv.FormatUint(uint64(b[i]&0xf), 16), strconv.FormatUint(uint64(b[i]>>4), 16))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa."
+}
+
+// Build returns the reverse entries of recs ordered by address, using
+// policy to choose the canonical names of shared addresses. Wildcard
+// hostnames have no reverse entries.
+func Build(recs []client.Record, policy string) ([]Entry, error) {
+	if !slices.Contains(Policies, policy) {
+		return nil, fmt.Errorf("invalid policy %q, must be one of: %s", policy, strings.Join(Policies, ", "))
+	}
+
+	byAddr := make(map[netip.Addr][]client.Record)
+	for _, r := range recs {
+		if strings.HasPrefix(r.Hostname, "*.") {
+			continue
+		}
+		addr := records.Addr(r.IP)
+		r.Hostname = records.NormalizeHostname(r.Hostname)
+		byAddr[addr] = append(byAddr[addr], r)
+	}
+
+	entries := make([]Entry, 0, len(byAddr))
+	for addr, rs := range byAddr {
+		slices.SortFunc(rs, compareFor(policy))
+		rs = slices.CompactFunc(rs, func(a, b client.Record) bool { return a.Hostname == b.Hostname })
+
+		canonical := rs[:1]
+		if policy == PolicyAll {
+			canonical = rs
+		}
+		e := Entry{IP: addr.String(), Name: ArpaName(net.IP(addr.AsSlice()))}
+		for _, r := range canonical {
+			e.Hostnames = append(e.Hostnames, r.Hostname)
+			if r.TTL > 0 && (e.TTL == 0 || r.TTL < e.TTL) {
+				e.TTL = r.TTL
+			}
+		}
+		for _, r := range rs[len(canonical):] {
+			e.Aliases = append(e.Aliases, r.Hostname)
+		}
+		slices.Sort(e.Aliases)
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return netip.MustParseAddr(a.IP).Compare(netip.MustParseAddr(b.IP))
+	})
+	return entries, nil
+}
+
+// compareFor orders the records of one address with the preferred
+// canonical name first.
+func compareFor(policy string) func(a, b client.Record) int {
+	shortest := func(a, b client.Record) int {
+		return cmp.Or(
+			cmp.Compare(strings.Count(a.Hostname, "."), strings.Count(b.Hostname, ".")),
+			cmp.Compare(len(a.Hostname), len(b.Hostname)),
+			strings.Compare(a.Hostname, b.Hostname),
+		)
+	}
+	switch policy {
+	case PolicyFirst, PolicyAll:
+		return func(a, b client.Record) int { return strings.Compare(a.Hostname, b.Hostname) }
+	case PolicyWeight:
+		return func(a, b client.Record) int {
+			return cmp.Or(cmp.Compare(weight(b), weight(a)), shortest(a, b))
+		}
+	}
+	return shortest
+}
+
+// weight returns the weight of a record, 1 if unset.
+func weight(r client.Record) int {
+	if r.Weight <= 0 {
+		return 1
+	}
+	return r.Weight
+}
+
+// Shared returns the entries of addresses shared by several hostnames.
+func Shared(entries []Entry) []Entry {
+	var shared []Entry
+	for _, e := range entries {
+		if e.Shared() {
+			shared = append(shared, e)
+		}
+	}
+	return shared
+}
+
+// Hosts returns the entries as hosts data with one record per address
+// and canonical name, the form a hosts based server answers PTR queries
+// from without ambiguity.
+func Hosts(entries []Entry) []client.Record {
+	var recs []client.Record
+	for _, e := range entries {
+		for _, h := range e.Hostnames {
+			recs = append(recs, client.Record{Hostname: h, IP: net.ParseIP(e.IP), TTL: e.TTL})
+		}
+	}
+	return recs
+}
+
+// Replace returns the stored PTR records current with those in the zone
+// origin replaced by the records of entries. Records outside the zone are
+// kept, so writing one zone leaves the others alone. An empty origin
+// replaces every record.
+func Replace(current []client.Record, entries []Entry, origin string) []client.Record {
+	var recs []client.Record
+	if origin != "" {
+		for _, r := range current {
+			if !InZone(ArpaName(r.IP), origin) {
+				recs = append(recs, r)
+			}
+		}
+	}
+	return append(recs, Hosts(entries)...)
+}
+
+// Origin returns the deepest zone containing every entry, on an octet
+// boundary for IPv4 and a nibble boundary for IPv6. A zone holds either
+// IPv4 or IPv6 addresses, so mixed entries are an error.
+func Origin(entries []Entry) (string, error) {
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no reverse entries")
+	}
+	// Leave at least one label for the owner names.
+	common := labels(entries[0].Name)[1:]
+	for _, e := range entries[1:] {
+		l := labels(e.Name)
+		n := 0
+		for n < len(common) && n < len(l) && common[len(common)-1-n] == l[len(l)-1-n] {
+			n++
+		}
+		common = common[len(common)-n:]
+	}
+	if len(common) < 2 {
+		return "", fmt.Errorf("IPv4 and IPv6 addresses do not fit one reverse zone")
+	}
+	return strings.Join(common, ".") + ".", nil
+}
+
+// labels splits a name with a trailing dot into its labels.
+func labels(name string) []string {
+	return strings.Split(strings.TrimSuffix(name, "."), ".")
+}
+
+// InZone reports whether name is origin or below it.
+func InZone(name, origin string) bool {
+	name, origin = records.NormalizeHostname(name), records.NormalizeHostname(origin)
+	return name == origin || strings.HasSuffix(name, "."+origin)
+}
+
+// Filter returns the entries in the zone origin.
+func Filter(entries []Entry, origin string) []Entry {
+	var in []Entry
+	for _, e := range entries {
+		if InZone(e.Name, origin) {
+			in = append(in, e)
+		}
+	}
+	return in
+}
+
+// ZoneOptions configure a zone file.
+type ZoneOptions struct {
+	// Origin is the zone name, e.g. 0.10.in-addr.arpa.
+	Origin string
+	// NS are the name servers of the zone; the first is the SOA primary.
+	NS []string
+	// Mail is the SOA mailbox as a domain name, e.g. hostmaster.example.com.
+	Mail string
+	// Serial is the SOA serial. The etcd revision of the data only grows,
+	// so it makes a valid serial.
+	Serial int64
+	// TTL is the default TTL of the zone and the negative caching TTL.
+	TTL uint32
+}
+
+// WriteZone writes entries as a BIND zone file. Entries outside the
+// origin must be filtered out first.
+func WriteZone(w io.Writer, entries []Entry, opts ZoneOptions) error {
+	origin := records.NormalizeHostname(opts.Origin)
+	if len(opts.NS) == 0 {
+		return fmt.Errorf("a zone needs at least one name server")
+	}
+	ns := make([]string, len(opts.NS))
+	for i, n := range opts.NS {
+		ns[i] = records.NormalizeHostname(n)
+	}
+	mail := opts.Mail
+	if mail == "" {
+		mail = "hostmaster." + ns[0]
+	}
+	mail = records.NormalizeHostname(strings.Replace(mail, "@", ".", 1))
+
+	ew := &errWriter{w: w}
+	ew.printf("; Reverse zone generated by dnsctl.\n")
+	ew.printf("$ORIGIN %s\n", origin)
+	ew.printf("$TTL %d\n", opts.TTL)
+	ew.printf("@\tIN\tSOA\t%s %s (\n", ns[0], mail)
+	ew.printf("\t\t%d\t; serial\n", opts.Serial)
+	ew.printf("\t\t3600\t; refresh\n")
+	ew.printf("\t\t600\t; retry\n")
+	ew.printf("\t\t604800\t; expire\n")
+	ew.printf("\t\t%d )\t; minimum\n", opts.TTL)
+	for _, n := range ns {
+		ew.printf("@\tIN\tNS\t%s\n", n)
+	}
+	for _, e := range entries {
+		owner := strings.TrimSuffix(strings.TrimSuffix(e.Name, origin), ".")
+		if owner == "" {
+			owner = "@"
+		}
+		ttl := ""
+		if e.TTL > 0 {
+			ttl = strconv.FormatUint(uint64(e.TTL), 10)
+		}
+		for _, h := range e.Hostnames {
+			ew.printf("%s\t%s\tIN\tPTR\t%s\n", owner, ttl, h)
+		}
+	}
+	return ew.err
+}
+
+// errWriter keeps the first write error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
@@ -0,0 +1,168 @@
+package ptr
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func TestArpaName(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.168.1.10", "10.1.168.192.in-addr.arpa."},
+		{"::ffff:10.0.0.1", "1.0.0.10.in-addr.arpa."},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range tests {
+		if got := ArpaName(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("ArpaName(%s) = %s, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func testRecords() []client.Record {
+	return []client.Record{
+		{Hostname: "web.example.com.", IP: net.ParseIP("10.0.0.2"), TTL: 300},
+		{Hostname: "app.svc.example.com.", IP: net.ParseIP("10.0.0.2"), Weight: 5, TTL: 60},
+		{Hostname: "www.example.com.", IP: net.ParseIP("10.0.0.2")},
+		{Hostname: "api.example.com.", IP: net.ParseIP("10.0.0.1")},
+		{Hostname: "*.example.com.", IP: net.ParseIP("10.0.0.9")},
+		{Hostname: "api.example.com.", IP: net.ParseIP("2001:db8::1")},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		policy    string
+		hostnames []string
+		aliases   []string
+		ttl       uint32
+	}{
+		{PolicyShortest, []string{"web.example.com."}, []string{"app.svc.example.com.", "www.example.com."}, 300},
+		{PolicyFirst, []string{"app.svc.example.com."}, []string{"web.example.com.", "www.example.com."}, 60},
+		{PolicyWeight, []string{"app.svc.example.com."}, []string{"web.example.com.", "www.example.com."}, 60},
+		{PolicyAll, []string{"app.svc.example.com.", "web.example.com.", "www.example.com."}, nil, 60},
+	}
+	for _, tt := range tests {
+		entries, err := Build(testRecords(), tt.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ips []string
+		for _, e := range entries {
+			ips = append(ips, e.IP)
+		}
+		if !slices.Equal(ips, []string{"10.0.0.1", "10.0.0.2", "2001:db8::1"}) {
+			t.Fatalf("Build(%s) addresses = %v, want no wildcard and v4 first", tt.policy, ips)
+		}
+		e := entries[1]
+		if !slices.Equal(e.Hostnames, tt.hostnames) || !slices.Equal(e.Aliases, tt.aliases) || e.TTL != tt.ttl {
+			t.Errorf("Build(%s) shared entry = %+v", tt.policy, e)
+		}
+		if got := Shared(entries); len(got) != 1 || got[0].IP != "10.0.0.2" {
+			t.Errorf("Shared() = %+v", got)
+		}
+	}
+
+	if _, err := Build(nil, "random"); err == nil {
+		t.Error("Build() with an unknown policy should fail")
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	entries, _ := Build(testRecords(), PolicyShortest)
+
+	if got, err := Origin(entries[:2]); err != nil || got != "0.0.10.in-addr.arpa." {
+		t.Errorf("Origin(v4) = %q, %v", got, err)
+	}
+	if got, err := Origin(entries[:1]); err != nil || got != "0.0.10.in-addr.arpa." {
+		t.Errorf("Origin(one address) = %q, %v, want the owner label kept", got, err)
+	}
+	if _, err := Origin(entries); err == nil {
+		t.Error("Origin() of both families should fail")
+	}
+	if !InZone("10.IN-ADDR.ARPA", "in-addr.arpa.") || InZone("example.com", "in-addr.arpa") {
+		t.Error("InZone() should match names below the origin only")
+	}
+	if got := Filter(entries, "10.in-addr.arpa"); len(got) != 2 {
+		t.Errorf("Filter() = %+v", got)
+	}
+}
+
+func TestWriteZone(t *testing.T) {
+	entries, _ := Build(testRecords(), PolicyShortest)
+	var b strings.Builder
+	err := WriteZone(&b, entries[:2], ZoneOptions{
+		Origin: "0.0.10.in-addr.arpa",
+		NS:     []string{"ns1.example.com"},
+		Mail:   "ops@example.com",
+		Serial: 42,
+		TTL:    3600,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `; Reverse zone generated by dnsctl.
+$ORIGIN 0.0.10.in-addr.arpa.
+$TTL 3600
+@	IN	SOA	ns1.example.com. ops.example.com. (
+		42	; serial
+		3600	; refresh
+		600	; retry
+		604800	; expire
+		3600 )	; minimum
+@	IN	NS	ns1.example.com.
+1		IN	PTR	api.example.com.
+2	300	IN	PTR	web.example.com.
+`
+	if b.String() != want {
+		t.Errorf("WriteZone() =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	if err := WriteZone(&b, entries, ZoneOptions{Origin: "arpa."}); err == nil {
+		t.Error("WriteZone() without name servers should fail")
+	}
+}
+
+func TestHosts(t *testing.T) {
+	entries, _ := Build(testRecords(), PolicyShortest)
+	recs := Hosts(entries)
+	if len(recs) != 3 || recs[1].Hostname != "web.example.com." || recs[1].TTL != 300 {
+		t.Errorf("Hosts() = %+v", recs)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	entries, _ := Build([]client.Record{
+		{Hostname: "a.example.com.", IP: net.ParseIP("10.0.0.1")},
+		{Hostname: "b.example.com.", IP: net.ParseIP("192.168.1.1")},
+	}, PolicyShortest)
+	stored := Replace(nil, entries, "")
+
+	// Rewriting 10.in-addr.arpa keeps the entries of 168.192.in-addr.arpa.
+	updated, _ := Build([]client.Record{{Hostname: "c.example.com.", IP: net.ParseIP("10.0.0.2")}}, PolicyShortest)
+	recs := Replace(stored, Filter(updated, "10.in-addr.arpa"), "10.in-addr.arpa")
+	var got []string
+	for _, r := range recs {
+		got = append(got, r.Hostname+" "+r.IP.String())
+	}
+	slices.Sort(got)
+	if want := []string{"b.example.com. 192.168.1.1", "c.example.com. 10.0.0.2"}; !slices.Equal(got, want) {
+		t.Errorf("Replace() = %v, want %v", got, want)
+	}
+
+	if recs := Replace(stored, nil, ""); len(recs) != 0 {
+		t.Errorf("Replace() without a zone = %v, want every record replaced", recs)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if got := Key("/etcdhosts/"); got != "/etcdhosts.ptr" {
+		t.Errorf("Key() = %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+package ptr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Entries are the reverse entries of a set of records.
+type Entries []Entry
+
+// Table implements output.Tabular.
+func (es Entries) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "IP"}, {Name: "HOSTNAME"}, {Name: "SHARED"},
+		{Name: "NAME", Wide: true}, {Name: "ALIASES", Wide: true}, {Name: "TTL", Wide: true},
+	}}
+	for _, e := range es {
+		shared := "-"
+		if e.Shared() {
+			shared = strconv.Itoa(len(e.Hostnames) + len(e.Aliases))
+		}
+		ttl := "-"
+		if e.TTL > 0 {
+			ttl = strconv.FormatUint(uint64(e.TTL), 10)
+		}
+		t.Rows = append(t.Rows, []string{
+			e.IP,
+			strings.Join(e.Hostnames, " "),
+			shared,
+			e.Name,
+			output.OrDash(strings.Join(e.Aliases, " ")),
+			ttl,
+		})
+	}
+	return t
+}
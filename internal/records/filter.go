@@ -0,0 +1,155 @@
+package records
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+// Filter selects records. Empty fields match every record; a record must
+// match all of the set fields, and any of the patterns or networks.
+type Filter struct {
+	// Patterns are hostnames or globs such as *.example.com, matched
+	// without regard to case or the trailing dot.
+	Patterns []string
+	// Networks contain the IPs of the selected records.
+	Networks []netip.Prefix
+	// HasHealth selects records with a health check.
+	HasHealth bool
+	// WeightGT selects records with a weight greater than it.
+	WeightGT *int
+	// TTL selects records with this TTL, 0 for records without one.
+	TTL *uint32
+	// Stack selects the records of hostnames in this stack, such as
+	// StackDual. It depends on all records of a hostname, so only Apply
+	// checks it.
+	Stack string
+}
+
+// ParsePattern checks a hostname glob and returns it normalized.
+func ParsePattern(s string) (string, error) {
+	p := NormalizeHostname(s)
+	if _, err := path.Match(p, ""); err != nil {
+		return "", fmt.Errorf("invalid hostname pattern %q", s)
+	}
+	return p, nil
+}
+
+// ParseNetwork parses a CIDR, or an IP as a network of one address.
+func ParseNetwork(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP or CIDR %q", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Match reports whether r is selected by f.
+func (f Filter) Match(r client.Record) bool {
+	if len(f.Patterns) > 0 && !slices.ContainsFunc(f.Patterns, func(p string) bool {
+		ok, _ := path.Match(NormalizeHostname(p), NormalizeHostname(r.Hostname))
+		return ok
+	}) {
+		return false
+	}
+	if len(f.Networks) > 0 {
+		addr := Addr(r.IP)
+		if !slices.ContainsFunc(f.Networks, func(n netip.Prefix) bool { return n.Contains(addr) }) {
+			return false
+		}
+	}
+	if f.HasHealth && r.Health == nil {
+		return false
+	}
+	if f.WeightGT != nil && Weight(r) <= *f.WeightGT {
+		return false
+	}
+	if f.TTL != nil && r.TTL != *f.TTL {
+		return false
+	}
+	return true
+}
+
+// Apply returns the records selected by f, in their original order.
+// The stack of a hostname is determined from all of recs.
+func (f Filter) Apply(recs []client.Record) []client.Record {
+	var families map[string]Families
+	if f.Stack != "" {
+		families = FamiliesOf(recs)
+	}
+	var out []client.Record
+	for _, r := range recs {
+		if f.Stack != "" && !families[NormalizeHostname(r.Hostname)].In(f.Stack) {
+			continue
+		}
+		if f.Match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Addr converts ip to a netip.Addr, with IPv4 addresses in their 4-byte
+// form.
+func Addr(ip net.IP) netip.Addr {
+	addr, _ := netip.AddrFromSlice(ip)
+	return addr.Unmap()
+}
+
+// CompareIP orders IPv4 addresses before IPv6 addresses and each family
+// numerically, so 10.0.0.9 sorts before 10.0.0.10.
+func CompareIP(a, b net.IP) int {
+	return Addr(a).Compare(Addr(b))
+}
+
+// Sort orders.
+const (
+	SortHostname = "hostname"
+	SortIP       = "ip"
+	SortWeight   = "weight"
+)
+
+// SortOrders are the orders accepted by Sort.
+var SortOrders = []string{SortHostname, SortIP, SortWeight}
+
+// Sort sorts recs in place by hostname, by IP, or by weight from the
+// heaviest, breaking ties by hostname and then IP.
+func Sort(recs []client.Record, by string) error {
+	byHostname := func(a, b client.Record) int {
+		return cmp.Or(
+			strings.Compare(NormalizeHostname(a.Hostname), NormalizeHostname(b.Hostname)),
+			CompareIP(a.IP, b.IP),
+		)
+	}
+
+	var compare func(a, b client.Record) int
+	switch by {
+	case SortHostname:
+		compare = byHostname
+	case SortIP:
+		compare = func(a, b client.Record) int {
+			return cmp.Or(CompareIP(a.IP, b.IP), byHostname(a, b))
+		}
+	case SortWeight:
+		compare = func(a, b client.Record) int {
+			return cmp.Or(cmp.Compare(Weight(b), Weight(a)), byHostname(a, b))
+		}
+	default:
+		return fmt.Errorf("unknown sort order %q (expected one of %s)", by, strings.Join(SortOrders, ", "))
+	}
+	slices.SortStableFunc(recs, compare)
+	return nil
+}
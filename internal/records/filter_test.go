@@ -0,0 +1,113 @@
+package records
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func filterRecords() []client.Record {
+	return []client.Record{
+		{Hostname: "web.example.com.", IP: net.ParseIP("10.0.0.10"), Weight: 3, TTL: 300},
+		{Hostname: "web.example.com.", IP: net.ParseIP("10.0.0.9"), Health: &client.Health{Type: client.CheckTCP, Port: 80}},
+		{Hostname: "api.example.com.", IP: net.ParseIP("2001:db8::1"), Weight: 2},
+		{Hostname: "db.internal.", IP: net.ParseIP("192.168.1.5"), TTL: 60},
+	}
+}
+
+func ips(recs []client.Record) []string {
+	out := make([]string, len(recs))
+	for i, r := range recs {
+		out[i] = r.IP.String()
+	}
+	return out
+}
+
+func TestFilter(t *testing.T) {
+	one, zero := 1, uint32(0)
+	ttl := uint32(300)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"empty", Filter{}, []string{"10.0.0.10", "10.0.0.9", "2001:db8::1", "192.168.1.5"}},
+		{"hostname", Filter{Patterns: []string{"DB.Internal"}}, []string{"192.168.1.5"}},
+		{"glob", Filter{Patterns: []string{"*.example.com"}}, []string{"10.0.0.10", "10.0.0.9", "2001:db8::1"}},
+		{"any pattern", Filter{Patterns: []string{"api.*", "db.*"}}, []string{"2001:db8::1", "192.168.1.5"}},
+		{"network", Filter{Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}, []string{"10.0.0.10", "10.0.0.9"}},
+		{"v6 network", Filter{Networks: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}}, []string{"2001:db8::1"}},
+		{"health", Filter{HasHealth: true}, []string{"10.0.0.9"}},
+		{"weight", Filter{WeightGT: &one}, []string{"10.0.0.10", "2001:db8::1"}},
+		{"ttl", Filter{TTL: &ttl}, []string{"10.0.0.10"}},
+		{"no ttl", Filter{TTL: &zero}, []string{"10.0.0.9", "2001:db8::1"}},
+		{"all of", Filter{Patterns: []string{"web.example.com"}, WeightGT: &one}, []string{"10.0.0.10"}},
+	}
+	for _, tt := range tests {
+		if got := ips(tt.filter.Apply(filterRecords())); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Apply() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseNetwork(t *testing.T) {
+	tests := map[string]string{
+		"10.1.2.3/8":  "10.0.0.0/8",
+		"10.1.2.3":    "10.1.2.3/32",
+		"2001:db8::1": "2001:db8::1/128",
+	}
+	for s, want := range tests {
+		if got, err := ParseNetwork(s); err != nil || got.String() != want {
+			t.Errorf("ParseNetwork(%s) = %s, %v, want %s", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"10.0.0/8", "10.0.0.0/33", "web"} {
+		if _, err := ParseNetwork(s); err == nil {
+			t.Errorf("ParseNetwork(%s) should fail", s)
+		}
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	if p, err := ParsePattern("*.Example.com"); err != nil || p != "*.example.com." {
+		t.Errorf("ParsePattern() = %q, %v", p, err)
+	}
+	if _, err := ParsePattern("[web"); err == nil {
+		t.Error("ParsePattern([web) should fail")
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := map[string][]string{
+		SortHostname: {"2001:db8::1", "192.168.1.5", "10.0.0.9", "10.0.0.10"},
+		SortIP:       {"10.0.0.9", "10.0.0.10", "192.168.1.5", "2001:db8::1"},
+		SortWeight:   {"10.0.0.10", "2001:db8::1", "192.168.1.5", "10.0.0.9"},
+	}
+	for by, want := range tests {
+		recs := filterRecords()
+		if err := Sort(recs, by); err != nil {
+			t.Fatalf("Sort(%s) error = %v", by, err)
+		}
+		if got := ips(recs); !slices.Equal(got, want) {
+			t.Errorf("Sort(%s) = %v, want %v", by, got, want)
+		}
+	}
+	if err := Sort(nil, "ttl"); err == nil {
+		t.Error("Sort(ttl) should fail")
+	}
+}
+
+func TestCompareIP(t *testing.T) {
+	if CompareIP(net.ParseIP("10.0.0.9"), net.ParseIP("10.0.0.10")) >= 0 {
+		t.Error("10.0.0.9 should sort before 10.0.0.10")
+	}
+	if CompareIP(net.ParseIP("::ffff:10.0.0.1"), net.ParseIP("10.0.0.1")) != 0 {
+		t.Error("an IPv4-mapped address should equal its IPv4 form")
+	}
+	if CompareIP(net.ParseIP("255.255.255.255"), net.ParseIP("::1")) >= 0 {
+		t.Error("IPv4 should sort before IPv6")
+	}
+}
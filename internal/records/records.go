@@ -0,0 +1,118 @@
+// Package records provides helpers for working with hosts records.
+package records
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+// NormalizeHostname returns hostname in the form stored by client-go:
+// lowercase with a trailing dot.
+func NormalizeHostname(hostname string) string {
+	hostname = strings.ToLower(hostname)
+	if !strings.HasSuffix(hostname, ".") {
+		hostname += "."
+	}
+	return hostname
+}
+
+// ParseHostname checks that s is a single hostname, as given on the
+// command line, and returns it normalized. It is parsed like a hosts
+// line, so text that would add fields, comments or lines is rejected.
+func ParseHostname(s string) (string, error) {
+	if s == "" || strings.ContainsFunc(s, unicode.IsSpace) {
+		return "", errors.New("must be a single hostname without whitespace")
+	}
+	res := client.ParseRecordsStrict([]byte("0.0.0.0 " + s))
+	if res.HasErrors() {
+		return "", errors.New(res.Errors[0].Reason)
+	}
+	name := NormalizeHostname(s)
+	if len(res.Records) != 1 || NormalizeHostname(res.Records[0].Hostname) != name {
+		return "", errors.New("must be a single hostname")
+	}
+	return name, nil
+}
+
+// Key identifies a record by hostname and IP, ignoring its attributes.
+func Key(r client.Record) string {
+	return NormalizeHostname(r.Hostname) + " " + r.IP.String()
+}
+
+// SameAttrs reports whether two records have the same weight, TTL and
+// health check.
+func SameAttrs(a, b client.Record) bool {
+	if a.Weight != b.Weight || a.TTL != b.TTL {
+		return false
+	}
+	if a.Health == nil || b.Health == nil {
+		return a.Health == b.Health
+	}
+	return *a.Health == *b.Health
+}
+
+// Index maps record keys to records.
+func Index(recs []client.Record) map[string]client.Record {
+	idx := make(map[string]client.Record, len(recs))
+	for _, r := range recs {
+		idx[Key(r)] = r
+	}
+	return idx
+}
+
+// Summary counts the differences between two sets of records.
+type Summary struct {
+	Added   int `json:"added" yaml:"added"`
+	Removed int `json:"removed" yaml:"removed"`
+	Changed int `json:"changed" yaml:"changed"`
+}
+
+// Summarize compares two sets of records by hostname and IP. Records
+// present in both with different attributes count as changed.
+func Summarize(oldRecs, newRecs []client.Record) Summary {
+	var s Summary
+	oldIdx := Index(oldRecs)
+	for _, r := range newRecs {
+		prev, ok := oldIdx[Key(r)]
+		switch {
+		case !ok:
+			s.Added++
+		case !SameAttrs(prev, r):
+			s.Changed++
+		}
+		delete(oldIdx, Key(r))
+	}
+	s.Removed = len(oldIdx)
+	return s
+}
+
+// Family returns "v6" for IPv6 records and "v4" otherwise.
+func Family(r client.Record) string {
+	if r.IP != nil && r.IP.To4() == nil {
+		return "v6"
+	}
+	return "v4"
+}
+
+// Weight returns the weight of r, treating unset weights as 1.
+func Weight(r client.Record) int {
+	return max(r.Weight, 1)
+}
+
+// Shares returns the share of each record, keyed by Key, in the answers
+// for its hostname and address family, according to the weights.
+func Shares(recs []client.Record) map[string]float64 {
+	totals := make(map[string]int)
+	for _, r := range recs {
+		totals[NormalizeHostname(r.Hostname)+" "+Family(r)] += Weight(r)
+	}
+
+	shares := make(map[string]float64, len(recs))
+	for _, r := range recs {
+		shares[Key(r)] = float64(Weight(r)) / float64(totals[NormalizeHostname(r.Hostname)+" "+Family(r)])
+	}
+	return shares
+}
@@ -0,0 +1,123 @@
+package records
+
+import (
+	"net"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"example.com", "example.com."},
+		{"example.com.", "example.com."},
+		{"API.Example.COM", "api.example.com."},
+	}
+
+	for _, tt := range tests {
+		if result := NormalizeHostname(tt.input); result != tt.expected {
+			t.Errorf("NormalizeHostname(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestParseHostname(t *testing.T) {
+	if name, err := ParseHostname("API.Example.com"); err != nil || name != "api.example.com." {
+		t.Errorf("ParseHostname(API.Example.com) = %q, %v, want api.example.com.", name, err)
+	}
+	for _, s := range []string{"", "a b", "x\n10.0.0.9 evil", "a\tb", "a#comment", "-bad.example.com"} {
+		if name, err := ParseHostname(s); err == nil {
+			t.Errorf("ParseHostname(%q) = %q, want an error", s, name)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := client.Record{Hostname: "Web.local", IP: net.ParseIP("10.0.0.1"), Weight: 1}
+	b := client.Record{Hostname: "web.local.", IP: net.ParseIP("10.0.0.1"), Weight: 5}
+
+	if Key(a) != Key(b) {
+		t.Errorf("Key() should ignore attributes and hostname case: %q != %q", Key(a), Key(b))
+	}
+	if Key(a) != "web.local. 10.0.0.1" {
+		t.Errorf("Key() = %q, want %q", Key(a), "web.local. 10.0.0.1")
+	}
+}
+
+func TestSameAttrs(t *testing.T) {
+	base := client.Record{Hostname: "web.local", IP: net.ParseIP("10.0.0.1"), Weight: 1}
+
+	tests := []struct {
+		name     string
+		other    client.Record
+		expected bool
+	}{
+		{"identical", base, true},
+		{"different IP only", client.Record{Hostname: "web.local", IP: net.ParseIP("10.0.0.2"), Weight: 1}, true},
+		{"weight", client.Record{Hostname: "web.local", IP: net.ParseIP("10.0.0.1"), Weight: 2}, false},
+		{"ttl", client.Record{Hostname: "web.local", IP: net.ParseIP("10.0.0.1"), Weight: 1, TTL: 60}, false},
+		{"health", client.Record{Hostname: "web.local", IP: net.ParseIP("10.0.0.1"), Weight: 1,
+			Health: &client.Health{Type: client.CheckTCP, Port: 80}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := SameAttrs(base, tt.other); result != tt.expected {
+				t.Errorf("SameAttrs() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	old, _ := client.ParseRecords([]byte(`10.0.0.1 web.local
+10.0.0.2 api.local # +etcdhosts weight=2
+10.0.0.3 db.local
+`))
+	newRecords, _ := client.ParseRecords([]byte(`10.0.0.1 web.local
+10.0.0.2 api.local # +etcdhosts weight=5
+10.0.0.4 cache.local
+10.0.0.5 cache.local
+`))
+
+	s := Summarize(old, newRecords)
+	expected := Summary{Added: 2, Removed: 1, Changed: 1}
+	if s != expected {
+		t.Errorf("Summarize() = %+v, want %+v", s, expected)
+	}
+
+	if s := Summarize(nil, old); s != (Summary{Added: 3}) {
+		t.Errorf("Summarize(nil, old) = %+v, want 3 added", s)
+	}
+}
+
+func TestShares(t *testing.T) {
+	recs := []client.Record{
+		{Hostname: "api.example.com.", IP: net.ParseIP("10.0.0.1"), Weight: 3},
+		{Hostname: "api.example.com.", IP: net.ParseIP("10.0.0.2")},
+		{Hostname: "API.example.com", IP: net.ParseIP("10.0.0.3"), Weight: 1},
+		{Hostname: "api.example.com.", IP: net.ParseIP("2001:db8::1"), Weight: 5},
+		{Hostname: "www.example.com.", IP: net.ParseIP("10.0.1.1"), Weight: 2},
+	}
+
+	shares := Shares(recs)
+	want := map[string]float64{
+		"api.example.com. 10.0.0.1":    0.6,
+		"api.example.com. 10.0.0.2":    0.2,
+		"api.example.com. 10.0.0.3":    0.2,
+		"api.example.com. 2001:db8::1": 1,
+		"www.example.com. 10.0.1.1":    1,
+	}
+	for key, share := range want {
+		if got := shares[key]; got != share {
+			t.Errorf("share of %s = %v, want %v", key, got, share)
+		}
+	}
+
+	if Family(recs[3]) != "v6" || Family(recs[0]) != "v4" {
+		t.Errorf("Family() = %s, %s, want v6, v4", Family(recs[3]), Family(recs[0]))
+	}
+}
@@ -0,0 +1,80 @@
+package records
+
+import (
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+// Match kinds of a resolution.
+const (
+	MatchExact    = "exact"
+	MatchWildcard = "wildcard"
+	MatchParent   = "parent"
+)
+
+// Resolution is the records that answer for a hostname.
+type Resolution struct {
+	Hostname string `json:"hostname" yaml:"hostname"`
+	// Match is how the records were found, empty if none were.
+	Match string `json:"match,omitempty" yaml:"match,omitempty"`
+	// Name is the hostname of the records, such as *.example.com. for a
+	// wildcard match.
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Answers []Answer `json:"records" yaml:"records"`
+}
+
+// Answer is a record with its share of the answers for its address
+// family, between 0 and 1.
+type Answer struct {
+	client.Record `yaml:",inline"`
+	Share         float64 `json:"share" yaml:"share"`
+}
+
+// Resolve returns the records of hostname. If there are none and
+// fallback is set, it walks up from hostname to the closest name with
+// records: at each level first the wildcard (*.example.com. for
+// api.example.com.), then the parent (example.com.).
+func Resolve(recs []client.Record, hostname string, fallback bool) Resolution {
+	hostname = NormalizeHostname(hostname)
+	res := Resolution{Hostname: hostname, Answers: []Answer{}}
+
+	byName := make(map[string][]client.Record)
+	for _, r := range recs {
+		name := NormalizeHostname(r.Hostname)
+		byName[name] = append(byName[name], r)
+	}
+
+	candidates := []struct{ name, match string }{{hostname, MatchExact}}
+	if fallback {
+		for parent := parentName(hostname); parent != ""; parent = parentName(parent) {
+			candidates = append(candidates,
+				struct{ name, match string }{"*." + parent, MatchWildcard},
+				struct{ name, match string }{parent, MatchParent})
+		}
+	}
+
+	for _, c := range candidates {
+		found := byName[c.name]
+		if len(found) == 0 {
+			continue
+		}
+		shares := Shares(found)
+		res.Match, res.Name = c.match, c.name
+		for _, r := range found {
+			res.Answers = append(res.Answers, Answer{Record: r, Share: shares[Key(r)]})
+		}
+		break
+	}
+	return res
+}
+
+// parentName returns the parent of a normalized hostname, or "" for a
+// top-level name such as com.
+func parentName(hostname string) string {
+	_, parent, ok := strings.Cut(hostname, ".")
+	if !ok || parent == "" || parent == "." {
+		return ""
+	}
+	return parent
+}
@@ -0,0 +1,49 @@
+package records
+
+import (
+	"net"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func TestResolve(t *testing.T) {
+	recs := []client.Record{
+		{Hostname: "api.example.com.", IP: net.ParseIP("10.0.0.1"), Weight: 3},
+		{Hostname: "api.example.com.", IP: net.ParseIP("10.0.0.2")},
+		{Hostname: "api.example.com.", IP: net.ParseIP("2001:db8::1")},
+		{Hostname: "*.example.com.", IP: net.ParseIP("10.0.1.1")},
+		{Hostname: "example.com.", IP: net.ParseIP("10.0.2.1")},
+		{Hostname: "internal.", IP: net.ParseIP("10.0.3.1")},
+	}
+	tests := []struct {
+		hostname string
+		fallback bool
+		match    string
+		name     string
+		answers  int
+	}{
+		{"API.example.com", false, MatchExact, "api.example.com.", 3},
+		{"web.example.com", false, "", "", 0},
+		{"web.example.com", true, MatchWildcard, "*.example.com.", 1},
+		{"v1.api.example.com", true, MatchParent, "api.example.com.", 3},
+		{"a.b.example.com", true, MatchWildcard, "*.example.com.", 1},
+		{"db.internal", true, MatchParent, "internal.", 1},
+		{"example.org", true, "", "", 0},
+	}
+	for _, tt := range tests {
+		res := Resolve(recs, tt.hostname, tt.fallback)
+		if res.Match != tt.match || res.Name != tt.name || len(res.Answers) != tt.answers {
+			t.Errorf("Resolve(%s, %v) = %s %s with %d answers, want %s %s with %d",
+				tt.hostname, tt.fallback, res.Match, res.Name, len(res.Answers), tt.match, tt.name, tt.answers)
+		}
+	}
+
+	res := Resolve(recs, "api.example.com", false)
+	shares := map[string]float64{"10.0.0.1": 0.75, "10.0.0.2": 0.25, "2001:db8::1": 1}
+	for _, a := range res.Answers {
+		if a.Share != shares[a.IP.String()] {
+			t.Errorf("share of %s = %v, want %v", a.IP, a.Share, shares[a.IP.String()])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package records
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+// CanonicalIP returns ip in canonical form: 4 bytes for IPv4, including
+// IPv4-mapped IPv6 addresses, and 16 bytes for IPv6. Spellings such as
+// 2001:db8::1 and 2001:0db8:0:0::1 give equal results.
+func CanonicalIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip.To16()
+}
+
+// Canonicalize returns r with its hostname normalized and its IP in
+// canonical form.
+func Canonicalize(r client.Record) client.Record {
+	r.Hostname = NormalizeHostname(r.Hostname)
+	r.IP = CanonicalIP(r.IP)
+	return r
+}
+
+// Stacks select hostnames by the address families they have records in.
+const (
+	// StackV4 selects hostnames with IPv4 records.
+	StackV4 = "v4"
+	// StackV6 selects hostnames with IPv6 records.
+	StackV6 = "v6"
+	// StackDual selects hostnames with records in both families.
+	StackDual = "dual"
+	// StackV4Only selects hostnames with IPv4 but no IPv6 records.
+	StackV4Only = "v4only"
+)
+
+// StackNames are the stacks accepted by Filter.
+var StackNames = []string{StackV4, StackV6, StackDual, StackV4Only}
+
+// ParseStack checks a stack name.
+func ParseStack(s string) (string, error) {
+	if !slices.Contains(StackNames, s) {
+		return "", fmt.Errorf("invalid stack %q, must be one of: %s", s, strings.Join(StackNames, ", "))
+	}
+	return s, nil
+}
+
+// Families are the address families a hostname has records in.
+type Families struct {
+	V4, V6 bool
+}
+
+// In reports whether a hostname with these families belongs to stack.
+func (f Families) In(stack string) bool {
+	switch stack {
+	case StackV4:
+		return f.V4
+	case StackV6:
+		return f.V6
+	case StackDual:
+		return f.V4 && f.V6
+	case StackV4Only:
+		return f.V4 && !f.V6
+	}
+	return false
+}
+
+// FamiliesOf returns the address families of every hostname in recs,
+// keyed by normalized hostname.
+func FamiliesOf(recs []client.Record) map[string]Families {
+	families := make(map[string]Families)
+	for _, r := range recs {
+		name := NormalizeHostname(r.Hostname)
+		f := families[name]
+		if Family(r) == "v6" {
+			f.V6 = true
+		} else {
+			f.V4 = true
+		}
+		families[name] = f
+	}
+	return families
+}
+
+// MissingFamilies returns a line for each hostname with records in only
+// one family, sorted by hostname. A dataset that uses a single family
+// throughout has none, since every hostname is then consistent.
+func MissingFamilies(recs []client.Record) []string {
+	families := FamiliesOf(recs)
+	var used Families
+	for _, f := range families {
+		used.V4 = used.V4 || f.V4
+		used.V6 = used.V6 || f.V6
+	}
+	if !used.V4 || !used.V6 {
+		return nil
+	}
+
+	var lines []string
+	for name, f := range families {
+		switch {
+		case !f.V6:
+			lines = append(lines, name+" has no IPv6 (AAAA) records")
+		case !f.V4:
+			lines = append(lines, name+" has no IPv4 (A) records")
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
@@ -0,0 +1,84 @@
+package records
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+)
+
+func TestCanonicalIP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		len  int
+	}{
+		{"2001:0db8:0:0::1", "2001:db8::1", 16},
+		{"2001:DB8:0000::0001", "2001:db8::1", 16},
+		{"::ffff:10.0.0.1", "10.0.0.1", 4},
+		{"10.0.0.1", "10.0.0.1", 4},
+	}
+	for _, tt := range tests {
+		got := CanonicalIP(net.ParseIP(tt.in))
+		if got.String() != tt.want || len(got) != tt.len {
+			t.Errorf("CanonicalIP(%s) = %s (%d bytes), want %s (%d bytes)", tt.in, got, len(got), tt.want, tt.len)
+		}
+	}
+
+	r := Canonicalize(client.Record{Hostname: "API.Example.com", IP: net.ParseIP("::ffff:10.0.0.1")})
+	if r.Hostname != "api.example.com." || len(r.IP) != 4 {
+		t.Errorf("Canonicalize() = %+v", r)
+	}
+}
+
+func stackRecords() []client.Record {
+	return []client.Record{
+		{Hostname: "dual.example.com.", IP: net.ParseIP("10.0.0.1")},
+		{Hostname: "dual.example.com.", IP: net.ParseIP("2001:db8::1")},
+		{Hostname: "v4.example.com.", IP: net.ParseIP("10.0.0.2")},
+		{Hostname: "v6.example.com.", IP: net.ParseIP("2001:db8::3")},
+	}
+}
+
+func TestFilter_Stack(t *testing.T) {
+	tests := []struct {
+		stack string
+		want  []string
+	}{
+		{StackV4, []string{"10.0.0.1", "2001:db8::1", "10.0.0.2"}},
+		{StackV6, []string{"10.0.0.1", "2001:db8::1", "2001:db8::3"}},
+		{StackDual, []string{"10.0.0.1", "2001:db8::1"}},
+		{StackV4Only, []string{"10.0.0.2"}},
+	}
+	for _, tt := range tests {
+		if got := ips(Filter{Stack: tt.stack}.Apply(stackRecords())); !slices.Equal(got, tt.want) {
+			t.Errorf("Apply(stack %s) = %v, want %v", tt.stack, got, tt.want)
+		}
+	}
+
+	// The stack depends on all records of a hostname, not only the ones
+	// selected by the other filters.
+	f := Filter{Stack: StackDual, Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	if got := ips(f.Apply(stackRecords())); !slices.Equal(got, []string{"10.0.0.1"}) {
+		t.Errorf("Apply(10.0.0.0/8, dual) = %v, want [10.0.0.1]", got)
+	}
+
+	if _, err := ParseStack("v6only"); err == nil {
+		t.Error("ParseStack(v6only) should fail")
+	}
+}
+
+func TestMissingFamilies(t *testing.T) {
+	want := []string{
+		"v4.example.com. has no IPv6 (AAAA) records",
+		"v6.example.com. has no IPv4 (A) records",
+	}
+	if got := MissingFamilies(stackRecords()); !slices.Equal(got, want) {
+		t.Errorf("MissingFamilies() = %v, want %v", got, want)
+	}
+	if got := MissingFamilies(stackRecords()[2:3]); got != nil {
+		t.Errorf("MissingFamilies() of an IPv4-only dataset = %v, want none", got)
+	}
+}
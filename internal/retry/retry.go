@@ -0,0 +1,125 @@
+// Package retry retries etcd operations that fail with transient errors.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policy bounds the retries of an operation.
+type Policy struct {
+	// Attempts is the total number of calls, including the first one.
+	Attempts int
+
+	// Initial is the wait before the first retry. It doubles on every
+	// retry up to Max, with jitter.
+	Initial time.Duration
+	Max     time.Duration
+
+	// OnRetry is called before waiting for a retry, if set.
+	OnRetry func(attempt int, err error, wait time.Duration)
+
+	// Wait makes every call run to completion, for functions that stop by
+	// themselves when the context is done. Otherwise a running call is
+	// abandoned when the context is done.
+	Wait bool
+}
+
+// Default is the policy used for hosts reads and writes.
+var Default = Policy{Attempts: 4, Initial: 200 * time.Millisecond, Max: 2 * time.Second}
+
+// retryableErrors are etcd errors that happen during leader elections or
+// while the connection to a member is lost.
+var retryableErrors = []error{
+	rpctypes.ErrNoLeader,
+	rpctypes.ErrLeaderChanged,
+	rpctypes.ErrTimeoutDueToLeaderFail,
+	rpctypes.ErrTimeoutDueToConnectionLost,
+}
+
+// Retryable reports whether err is a transient etcd error.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range retryableErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return status.Code(err) == codes.Unavailable
+}
+
+// Do calls fn until it succeeds, fails with an error that is not
+// retryable, or the attempts are used up. When ctx is done, Do returns its
+// cause right away without waiting for a running call, as client-go calls
+// cannot be canceled, unless the policy sets Wait.
+func Do(ctx context.Context, p Policy, fn func() error) error {
+	_, err := Value(ctx, p, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// Value is Do for functions that return a value.
+func Value[T any](ctx context.Context, p Policy, fn func() (T, error)) (T, error) {
+	wait := p.Initial
+	for attempt := 1; ; attempt++ {
+		v, err := call(ctx, p.Wait, fn)
+		if err == nil || ctx.Err() != nil || !Retryable(err) || attempt >= p.Attempts {
+			return v, err
+		}
+
+		d := jitter(min(wait, p.Max))
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, d)
+		}
+		select {
+		case <-ctx.Done():
+			return v, context.Cause(ctx)
+		case <-time.After(d):
+		}
+		wait *= 2
+	}
+}
+
+// call runs fn and, unless wait is set, returns early when ctx is done.
+func call[T any](ctx context.Context, wait bool, fn func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+
+	var zero T
+	if ctx.Err() != nil {
+		return zero, context.Cause(ctx)
+	}
+	if wait {
+		return fn()
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		return zero, context.Cause(ctx)
+	}
+}
+
+// jitter returns a random duration in [d/2, d].
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
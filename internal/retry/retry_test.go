@@ -0,0 +1,132 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var fast = Policy{Attempts: 3, Initial: time.Millisecond, Max: 2 * time.Millisecond}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("version conflict"), false},
+		{rpctypes.ErrLeaderChanged, true},
+		{fmt.Errorf("failed to get meta: %w", rpctypes.ErrNoLeader), true},
+		{fmt.Errorf("read: %w", status.Error(codes.Unavailable, "connection refused")), true},
+		{status.Error(codes.PermissionDenied, "denied"), false},
+		{rpctypes.ErrAuthFailed, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Run("succeeds after retries", func(t *testing.T) {
+		calls, retries := 0, 0
+		p := fast
+		p.OnRetry = func(int, error, time.Duration) { retries++ }
+		err := Do(context.Background(), p, func() error {
+			if calls++; calls < 3 {
+				return rpctypes.ErrLeaderChanged
+			}
+			return nil
+		})
+		if err != nil || calls != 3 || retries != 2 {
+			t.Errorf("Do() = %v after %d calls and %d retries, want nil after 3 and 2", err, calls, retries)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		calls := 0
+		err := Do(context.Background(), fast, func() error {
+			calls++
+			return rpctypes.ErrNoLeader
+		})
+		if !errors.Is(err, rpctypes.ErrNoLeader) || calls != fast.Attempts {
+			t.Errorf("Do() = %v after %d calls, want ErrNoLeader after %d", err, calls, fast.Attempts)
+		}
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		calls := 0
+		conflict := errors.New("version conflict")
+		err := Do(context.Background(), fast, func() error {
+			calls++
+			return conflict
+		})
+		if err != conflict || calls != 1 {
+			t.Errorf("Do() = %v after %d calls, want conflict after 1", err, calls)
+		}
+	})
+}
+
+func TestValue_Canceled(t *testing.T) {
+	cause := errors.New("interrupted")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel(cause)
+	}()
+
+	start := time.Now()
+	_, err := Value(ctx, fast, func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	if err != cause {
+		t.Errorf("Value() error = %v, want %v", err, cause)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Value() should return as soon as the context is done")
+	}
+
+	if _, err := Value(ctx, fast, func() (int, error) { return 1, nil }); err != cause {
+		t.Errorf("Value() with a done context = %v, want %v", err, cause)
+	}
+}
+
+func TestValue_Wait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := fast
+	p.Wait = true
+
+	finished := false
+	_, err := Value(ctx, p, func() (int, error) {
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		finished = true
+		return 0, ctx.Err()
+	})
+	if !finished {
+		t.Error("Value() with Wait should not return before the call finished")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Value() error = %v, want the error of the call", err)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		if d := jitter(100 * time.Millisecond); d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Fatalf("jitter() = %s, want within [50ms, 100ms]", d)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package schedule stores hosts changes that are applied at a later time.
+package schedule
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/audit"
+	"github.com/etcdhosts/dnsctl/v2/internal/errdefs"
+)
+
+// ErrNotFound is returned when a scheduled change does not exist.
+var ErrNotFound = errdefs.New(errdefs.NotFound, "scheduled change not found")
+
+// ErrNotPending is returned when a change is no longer pending,
+// e.g. it was canceled or claimed by another worker.
+var ErrNotPending = errdefs.New(errdefs.Conflict, "scheduled change is not pending")
+
+// ErrClaimLost is returned by Finish when the change was modified while it
+// was applied, e.g. canceled as stale. The outcome is not recorded.
+var ErrClaimLost = errdefs.New(errdefs.Conflict, "claim of scheduled change lost")
+
+// ClaimTimeout is how long a change may stay running. Applying a change
+// takes seconds, so a claim older than this belongs to a worker that died
+// before recording the outcome; such a change can be canceled.
+const ClaimTimeout = 10 * time.Minute
+
+// Status represents the state of a scheduled change.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusRunning  Status = "running"
+	StatusApplied  Status = "applied"
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+	StatusUnknown  Status = "unknown"
+)
+
+// Change is a full hosts data set to be written at a given time.
+type Change struct {
+	ID          string      `json:"id" yaml:"id"`
+	At          time.Time   `json:"at" yaml:"at"`
+	Created     time.Time   `json:"created" yaml:"created"`
+	BaseVersion int64       `json:"base_version" yaml:"base_version"`
+	Records     int         `json:"records" yaml:"records"`
+	Content     string      `json:"content" yaml:"content"`
+	Audit       audit.Entry `json:"audit" yaml:"audit"`
+	Status      Status      `json:"status" yaml:"status"`
+	Claimed     time.Time   `json:"claimed,omitzero" yaml:"claimed,omitempty"`
+	Finished    time.Time   `json:"finished,omitzero" yaml:"finished,omitempty"`
+	Revision    int64       `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Error       string      `json:"error,omitempty" yaml:"error,omitempty"`
+
+	modRevision int64
+}
+
+// Stale reports whether c is running with a claim older than ClaimTimeout.
+func (c *Change) Stale(now time.Time) bool {
+	return c.Status == StatusRunning && now.Sub(c.Claimed) > ClaimTimeout
+}
+
+// Store keeps scheduled changes in etcd under a key prefix.
+type Store struct {
+	kv      clientv3.KV
+	prefix  string
+	timeout time.Duration
+}
+
+// KeyPrefix returns the schedule prefix for a hosts key.
+// It is a sibling of the hosts key so that per-host data is never touched.
+func KeyPrefix(hostsKey string) string {
+	return strings.TrimSuffix(hostsKey, "/") + ".schedule/"
+}
+
+// NewStore creates a Store using prefix for all keys.
+func NewStore(kv clientv3.KV, prefix string, timeout time.Duration) *Store {
+	return &Store{kv: kv, prefix: prefix, timeout: timeout}
+}
+
+// Add stores a new pending change and assigns its ID.
+func (s *Store) Add(ctx context.Context, c *Change) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if c.Created.IsZero() {
+		c.Created = time.Now().UTC()
+	}
+	c.Status = StatusPending
+	c.ID = newID(c.At)
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	key := s.prefix + c.ID
+	resp, err := s.kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to store scheduled change: %w", err)
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("scheduled change %s already exists", c.ID)
+	}
+	c.modRevision = resp.Header.Revision
+	return nil
+}
+
+// List returns all scheduled changes ordered by apply time.
+func (s *Store) List(ctx context.Context) ([]*Change, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := s.kv.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled changes: %w", err)
+	}
+
+	var changes []*Change
+	for _, kv := range resp.Kvs {
+		var c Change
+		if err := json.Unmarshal(kv.Value, &c); err != nil {
+			continue
+		}
+		c.modRevision = kv.ModRevision
+		changes = append(changes, &c)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if !changes[i].At.Equal(changes[j].At) {
+			return changes[i].At.Before(changes[j].At)
+		}
+		return changes[i].ID < changes[j].ID
+	})
+	return changes, nil
+}
+
+// Get returns a single scheduled change.
+func (s *Store) Get(ctx context.Context, id string) (*Change, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := s.kv.Get(ctx, s.prefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled change %s: %w", id, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	var c Change
+	if err := json.Unmarshal(resp.Kvs[0].Value, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduled change %s: %w", id, err)
+	}
+	c.modRevision = resp.Kvs[0].ModRevision
+	return &c, nil
+}
+
+// Cancel marks a pending change, or a stale running one, as canceled.
+func (s *Store) Cancel(ctx context.Context, id string) error {
+	c, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.Stale(time.Now()) {
+		return s.transition(ctx, c, StatusRunning, StatusCanceled)
+	}
+	return s.transition(ctx, c, StatusPending, StatusCanceled)
+}
+
+// Claim marks a pending change as running so that only one worker applies it.
+// Returns ErrNotPending if the change was canceled or claimed concurrently.
+func (s *Store) Claim(ctx context.Context, c *Change) error {
+	return s.transition(ctx, c, StatusPending, StatusRunning)
+}
+
+// Finish records the outcome of applying a claimed change.
+// A nil err marks the change applied at the given revision, and an
+// errdefs.ErrOutcomeUnknown error marks it unknown rather than failed.
+// Like Claim it is a compare-and-swap: if the change was modified since
+// it was claimed, e.g. canceled as stale, nothing is recorded and
+// ErrClaimLost is returned.
+func (s *Store) Finish(ctx context.Context, c *Change, revision int64, applyErr error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	next := *c
+	next.Finished = time.Now().UTC()
+	switch {
+	case errors.Is(applyErr, errdefs.ErrOutcomeUnknown):
+		next.Status = StatusUnknown
+		next.Error = applyErr.Error()
+	case applyErr != nil:
+		next.Status = StatusFailed
+		next.Error = applyErr.Error()
+	default:
+		next.Status = StatusApplied
+		next.Revision = revision
+	}
+	return s.put(ctx, c, &next, ErrClaimLost)
+}
+
+// transition moves a change from status from to status.
+func (s *Store) transition(ctx context.Context, c *Change, from, status Status) error {
+	if c.Status != from {
+		return fmt.Errorf("%w: %s is %s", ErrNotPending, c.ID, c.Status)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	next := *c
+	next.Status = status
+	switch status {
+	case StatusRunning:
+		next.Claimed = time.Now().UTC()
+	case StatusCanceled:
+		next.Finished = time.Now().UTC()
+	}
+	return s.put(ctx, c, &next, ErrNotPending)
+}
+
+// put replaces c with next using a compare-and-swap on the key's mod
+// revision, failing with conflict if c was modified since it was read.
+func (s *Store) put(ctx context.Context, c, next *Change, conflict error) error {
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+
+	key := s.prefix + c.ID
+	resp, err := s.kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", c.modRevision)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled change %s: %w", c.ID, err)
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("%w: %s was modified concurrently", conflict, c.ID)
+	}
+
+	*c = *next
+	c.modRevision = resp.Header.Revision
+	return nil
+}
+
+// Due returns the pending changes whose apply time is not after now.
+func Due(changes []*Change, now time.Time) []*Change {
+	var due []*Change
+	for _, c := range changes {
+		if c.Status == StatusPending && !c.At.After(now) {
+			due = append(due, c)
+		}
+	}
+	return due
+}
+
+// newID returns a sortable, human friendly ID like 20261101T0200-3fa9c1.
+func newID(at time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return at.UTC().Format("20060102T1504") + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"/etcdhosts", "/etcdhosts.schedule/"},
+		{"/dns/records", "/dns/records.schedule/"},
+		{"/etcdhosts/", "/etcdhosts.schedule/"},
+	}
+
+	for _, tt := range tests {
+		if result := KeyPrefix(tt.key); result != tt.expected {
+			t.Errorf("KeyPrefix(%q) = %q, want %q", tt.key, result, tt.expected)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC)
+	changes := []*Change{
+		{ID: "past", At: now.Add(-time.Hour), Status: StatusPending},
+		{ID: "now", At: now, Status: StatusPending},
+		{ID: "future", At: now.Add(time.Minute), Status: StatusPending},
+		{ID: "applied", At: now.Add(-time.Hour), Status: StatusApplied},
+		{ID: "canceled", At: now.Add(-time.Hour), Status: StatusCanceled},
+		{ID: "running", At: now.Add(-time.Hour), Status: StatusRunning},
+	}
+
+	due := Due(changes, now)
+	if len(due) != 2 {
+		t.Fatalf("Due() returned %d changes, want 2", len(due))
+	}
+	if due[0].ID != "past" || due[1].ID != "now" {
+		t.Errorf("Due() = [%s %s], want [past now]", due[0].ID, due[1].ID)
+	}
+}
+
+func TestStale(t *testing.T) {
+	now := time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		change Change
+		stale  bool
+	}{
+		{"fresh claim", Change{Status: StatusRunning, Claimed: now.Add(-time.Minute)}, false},
+		{"old claim", Change{Status: StatusRunning, Claimed: now.Add(-ClaimTimeout - time.Second)}, true},
+		{"claim without time", Change{Status: StatusRunning}, true},
+		{"pending", Change{Status: StatusPending}, false},
+		{"failed", Change{Status: StatusFailed, Claimed: now.Add(-time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		if result := tt.change.Stale(now); result != tt.stale {
+			t.Errorf("%s: Stale() = %v, want %v", tt.name, result, tt.stale)
+		}
+	}
+}
+
+func TestNewID(t *testing.T) {
+	at := time.Date(2026, 11, 1, 10, 0, 0, 0, time.FixedZone("CST", 8*3600))
+
+	id := newID(at)
+	if !regexp.MustCompile(`^20261101T0200-[0-9a-f]{6}$`).MatchString(id) {
+		t.Errorf("newID() = %q, want 20261101T0200-xxxxxx", id)
+	}
+	if newID(at) == id {
+		t.Error("newID() should not repeat for the same time")
+	}
+}
@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Changes are scheduled changes.
+type Changes []*Change
+
+// Table implements output.Tabular.
+func (cs Changes) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "ID"}, {Name: "AT"}, {Name: "STATUS"}, {Name: "BASE"}, {Name: "RECORDS"}, {Name: "RESULT"},
+		{Name: "CREATED", Wide: true}, {Name: "FINISHED", Wide: true}, {Name: "AUTHOR", Wide: true}, {Name: "MESSAGE", Wide: true},
+	}}
+	for _, c := range cs {
+		t.Rows = append(t.Rows, []string{
+			c.ID,
+			output.FormatTime(c.At),
+			string(c.Status),
+			strconv.FormatInt(c.BaseVersion, 10),
+			strconv.Itoa(c.Records),
+			c.Outcome(),
+			output.FormatTime(c.Created),
+			output.FormatTime(c.Finished),
+			output.OrDash(c.Audit.User),
+			output.OrDash(c.Audit.Message),
+		})
+	}
+	return t
+}
+
+// Outcome describes the outcome of the change for tables.
+func (c *Change) Outcome() string {
+	switch c.Status {
+	case StatusApplied:
+		return fmt.Sprintf("revision %d", c.Revision)
+	case StatusFailed, StatusUnknown:
+		return c.Error
+	case StatusRunning:
+		if c.Stale(time.Now()) {
+			return "stale claim from " + output.FormatTime(c.Claimed) + ", can be canceled"
+		}
+	}
+	return "-"
+}
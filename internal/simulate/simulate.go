@@ -0,0 +1,124 @@
+// Package simulate predicts how the etcdhosts plugin spreads the answers
+// for a hostname over its records.
+package simulate
+
+import (
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"slices"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/records"
+)
+
+// Options configure a simulation.
+type Options struct {
+	// Queries is the number of queries per address family.
+	Queries int
+	// Down are the IPs, or networks, whose health checks fail.
+	Down []netip.Prefix
+	// Seed makes the simulation repeatable; 0 uses a random seed.
+	Seed uint64
+}
+
+// Result is the simulated distribution of the answers for a hostname.
+type Result struct {
+	Hostname string    `json:"hostname" yaml:"hostname"`
+	Queries  int       `json:"queries" yaml:"queries"`
+	Backends []Backend `json:"backends" yaml:"backends"`
+	// FailOpen lists the families whose records are all down, which are
+	// then answered as if all were up.
+	FailOpen []string `json:"fail_open,omitempty" yaml:"fail_open,omitempty"`
+}
+
+// Backend is the expected and simulated share of one record, between 0
+// and 1, within its address family.
+type Backend struct {
+	IP        net.IP  `json:"ip" yaml:"ip"`
+	Family    string  `json:"family" yaml:"family"`
+	Weight    int     `json:"weight" yaml:"weight"`
+	Down      bool    `json:"down" yaml:"down"`
+	Expected  float64 `json:"expected" yaml:"expected"`
+	Answers   int     `json:"answers" yaml:"answers"`
+	Simulated float64 `json:"simulated" yaml:"simulated"`
+}
+
+// Run simulates opts.Queries A and AAAA queries for the records of one
+// hostname. Like the plugin, each query is answered with one record of
+// the family, picked at random in proportion to its weight among the
+// records that are up. If every record of a family is down, all of them
+// are used, so that the hostname keeps resolving.
+func Run(hostname string, recs []client.Record, opts Options) Result {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(seed, seed))
+
+	res := Result{Hostname: records.NormalizeHostname(hostname), Queries: opts.Queries, Backends: []Backend{}}
+	for _, family := range []string{"v4", "v6"} {
+		var backends []Backend
+		for _, r := range recs {
+			if records.Family(r) != family {
+				continue
+			}
+			addr := records.Addr(r.IP)
+			backends = append(backends, Backend{
+				IP:     r.IP,
+				Family: family,
+				Weight: records.Weight(r),
+				Down:   slices.ContainsFunc(opts.Down, func(n netip.Prefix) bool { return n.Contains(addr) }),
+			})
+		}
+		if len(backends) == 0 {
+			continue
+		}
+
+		eligible := func(b Backend) bool { return !b.Down }
+		if !slices.ContainsFunc(backends, eligible) {
+			eligible = func(Backend) bool { return true }
+			res.FailOpen = append(res.FailOpen, family)
+		}
+
+		total := 0
+		for _, b := range backends {
+			if eligible(b) {
+				total += b.Weight
+			}
+		}
+		for i := range backends {
+			if eligible(backends[i]) {
+				backends[i].Expected = float64(backends[i].Weight) / float64(total)
+			}
+		}
+
+		for range opts.Queries {
+			backends[pick(rng, backends, eligible, total)].Answers++
+		}
+		for i := range backends {
+			if opts.Queries > 0 {
+				backends[i].Simulated = float64(backends[i].Answers) / float64(opts.Queries)
+			}
+		}
+		res.Backends = append(res.Backends, backends...)
+	}
+	return res
+}
+
+// pick returns the index of a backend chosen in proportion to its weight
+// among the eligible ones, whose weights add up to total.
+func pick(rng *rand.Rand, backends []Backend, eligible func(Backend) bool, total int) int {
+	n := rng.IntN(total)
+	for i, b := range backends {
+		if !eligible(b) {
+			continue
+		}
+		if n < b.Weight {
+			return i
+		}
+		n -= b.Weight
+	}
+	return len(backends) - 1
+}
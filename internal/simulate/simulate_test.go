@@ -0,0 +1,100 @@
+package simulate
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+
+	client "github.com/etcdhosts/client-go/v2"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+func testRecords() []client.Record {
+	return []client.Record{
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1"), Weight: 3},
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.2"), Weight: 1},
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.3")},
+		{Hostname: "api.local.", IP: net.ParseIP("2001:db8::1"), Weight: 2},
+	}
+}
+
+func shares(res Result) map[string][2]float64 {
+	out := make(map[string][2]float64)
+	for _, b := range res.Backends {
+		out[b.IP.String()] = [2]float64{b.Expected, b.Simulated}
+	}
+	return out
+}
+
+func TestRun(t *testing.T) {
+	res := Run("API.local", testRecords(), Options{Queries: 20000, Seed: 1})
+	if res.Hostname != "api.local." || len(res.Backends) != 4 || res.FailOpen != nil {
+		t.Fatalf("Run() = %+v", res)
+	}
+
+	want := map[string]float64{"10.0.0.1": 0.6, "10.0.0.2": 0.2, "10.0.0.3": 0.2, "2001:db8::1": 1}
+	for ip, s := range shares(res) {
+		if math.Abs(s[0]-want[ip]) > 1e-9 {
+			t.Errorf("expected share of %s = %v, want %v", ip, s[0], want[ip])
+		}
+		if math.Abs(s[1]-want[ip]) > 0.02 {
+			t.Errorf("simulated share of %s = %v, want about %v", ip, s[1], want[ip])
+		}
+	}
+
+	again := Run("api.local", testRecords(), Options{Queries: 20000, Seed: 1})
+	if !slices.EqualFunc(res.Backends, again.Backends, func(a, b Backend) bool { return a.Answers == b.Answers }) {
+		t.Error("Run() with the same seed should give the same answers")
+	}
+}
+
+func TestRun_Down(t *testing.T) {
+	res := Run("api.local", testRecords(), Options{
+		Queries: 1000,
+		Seed:    1,
+		Down:    []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")},
+	})
+	got := shares(res)
+	if got["10.0.0.1"] != [2]float64{0, 0} {
+		t.Errorf("down record got share %v", got["10.0.0.1"])
+	}
+	if got["10.0.0.2"][0] != 0.5 || got["10.0.0.3"][0] != 0.5 {
+		t.Errorf("expected shares = %v", got)
+	}
+}
+
+func TestRun_FailOpen(t *testing.T) {
+	res := Run("api.local", testRecords(), Options{
+		Queries: 1000,
+		Seed:    1,
+		Down:    []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")},
+	})
+	if !slices.Equal(res.FailOpen, []string{"v4"}) {
+		t.Errorf("FailOpen = %v, want [v4]", res.FailOpen)
+	}
+	if got := shares(res)["10.0.0.1"][0]; got != 0.6 {
+		t.Errorf("expected share with all down = %v, want 0.6", got)
+	}
+}
+
+func TestResult_Table(t *testing.T) {
+	res := Run("api.local", []client.Record{
+		{Hostname: "api.local.", IP: net.ParseIP("10.0.0.1"), Weight: 3},
+		{Hostname: "api.local.", IP: net.ParseIP("2001:db8::1")},
+	}, Options{Queries: 100, Seed: 1})
+
+	var buf bytes.Buffer
+	output.WriteTable(&buf, res.Table(), true)
+	want := `IP           FAMILY  WEIGHT  STATE  EXPECTED  SIMULATED  ANSWERS  DIFF
+-----------  ------  ------  -----  --------  ---------  -------  ----
+10.0.0.1     v4      3       up     100.0%    100.0%     100      +0.0
+2001:db8::1  v6      1       up     100.0%    100.0%     100      +0.0
+`
+	if buf.String() != want {
+		t.Errorf("Table() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
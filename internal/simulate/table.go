@@ -0,0 +1,35 @@
+package simulate
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/etcdhosts/dnsctl/v2/internal/output"
+)
+
+// Table implements output.Tabular. It shows the expected and simulated
+// share of each backend, with the difference in percentage points.
+func (res Result) Table() output.Table {
+	t := output.Table{Columns: []output.Column{
+		{Name: "IP"}, {Name: "FAMILY"}, {Name: "WEIGHT"}, {Name: "STATE"},
+		{Name: "EXPECTED"}, {Name: "SIMULATED"}, {Name: "ANSWERS"},
+		{Name: "DIFF", Wide: true},
+	}}
+	for _, b := range res.Backends {
+		state := "up"
+		if b.Down {
+			state = "down"
+		}
+		t.Rows = append(t.Rows, []string{
+			b.IP.String(),
+			b.Family,
+			strconv.Itoa(b.Weight),
+			state,
+			output.FormatShare(b.Expected),
+			output.FormatShare(b.Simulated),
+			strconv.Itoa(b.Answers),
+			fmt.Sprintf("%+.1f", (b.Simulated-b.Expected)*100),
+		})
+	}
+	return t
+}
@@ -0,0 +1,39 @@
+// Package timeutil provides time parsing helpers for command flags.
+package timeutil
+
+import (
+	"fmt"
+	"time"
+)
+
+// layouts lists the accepted absolute time formats, most specific first.
+// Layouts without a zone are interpreted in local time.
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime parses a user supplied time such as "2026-11-01T02:00Z"
+// or "2026-11-01 02:00".
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s (expected e.g. 2006-01-02T15:04Z)", s)
+}
+
+// ParseSince parses a time like ParseTime, or a duration such as "24h"
+// meaning that long before now.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return ParseTime(s)
+}
@@ -0,0 +1,84 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Time
+	}{
+		{
+			name:     "RFC3339",
+			input:    "2026-11-01T02:00:00Z",
+			expected: time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "without seconds UTC",
+			input:    "2026-11-01T02:00Z",
+			expected: time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "with offset",
+			input:    "2026-11-01T10:00+08:00",
+			expected: time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "local time",
+			input:    "2026-11-01 02:00",
+			expected: time.Date(2026, 11, 1, 2, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "date only",
+			input:    "2026-11-01",
+			expected: time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseTime(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTime(%q) error = %v", tt.input, err)
+			}
+			if !result.Equal(tt.expected) {
+				t.Errorf("ParseTime(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTime_Invalid(t *testing.T) {
+	for _, input := range []string{"", "tomorrow", "2026-13-01"} {
+		if _, err := ParseTime(input); err == nil {
+			t.Errorf("ParseTime(%q) expected error", input)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	result, err := ParseSince("24h", now)
+	if err != nil {
+		t.Fatalf("ParseSince(24h) error = %v", err)
+	}
+	if !result.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("ParseSince(24h) = %v, want %v", result, now.Add(-24*time.Hour))
+	}
+
+	result, err = ParseSince("2026-10-01T00:00Z", now)
+	if err != nil {
+		t.Fatalf("ParseSince(absolute) error = %v", err)
+	}
+	if !result.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseSince(absolute) = %v", result)
+	}
+
+	if _, err := ParseSince("yesterday", now); err == nil {
+		t.Error("ParseSince(yesterday) expected error")
+	}
+}